@@ -52,9 +52,9 @@ func (cerr *ChecksumError) Error() string {
 }
 
 // checksum is a simple method to compute the checksum of a source file
-// and compare it to the given expected value.
-func (c *FileChecksum) checksum(source string) error {
-	f, err := os.Open(source)
+// on fsys and compare it to the given expected value.
+func (c *FileChecksum) checksum(fsys WritableFS, source string) error {
+	f, err := fsys.OpenFile(source, os.O_RDONLY, 0)
 	if err != nil {
 		return fmt.Errorf("failed to open file for checksum: %w", err)
 	}
@@ -65,12 +65,18 @@ func (c *FileChecksum) checksum(source string) error {
 		return fmt.Errorf("failed to hash: %w", err)
 	}
 
+	return c.verify(source)
+}
+
+// verify compares the sum of what was written to the Hash of c, which
+// was read from file, to the expected value.
+func (c *FileChecksum) verify(file string) error {
 	if actual := c.Hash.Sum(nil); !bytes.Equal(actual, c.Value) {
 		return &ChecksumError{
 			Hash:     c.Hash,
 			Actual:   actual,
 			Expected: c.Value,
-			File:     source,
+			File:     file,
 		}
 	}
 
@@ -101,11 +107,20 @@ func (c *FileChecksum) checksum(source string) error {
 //
 // see parseChecksumLine for more detail on checksum file parsing
 func (c *Client) extractChecksum(u *url.URL) (*FileChecksum, error) {
-	q := u.Query()
-	v := q.Get("checksum")
+	checksumFile, checksum, err := parseChecksumParam(u.Query().Get("checksum"), u)
+	if err != nil || checksumFile == "" {
+		return checksum, err
+	}
+	return c.ChecksumFromFile(checksumFile, u)
+}
 
+// parseChecksumParam parses the value v of the 'checksum' parameter of u
+// without downloading anything. When the parameter uses the
+// file:<checksum_url> form, the checksum URL is returned instead of a
+// FileChecksum.
+func parseChecksumParam(v string, u *url.URL) (string, *FileChecksum, error) {
 	if v == "" {
-		return nil, nil
+		return "", nil, nil
 	}
 
 	vs := strings.SplitN(v, ":", 2)
@@ -115,16 +130,18 @@ func (c *Client) extractChecksum(u *url.URL) (*FileChecksum, error) {
 	default:
 		// here, we try to guess the checksum from it's length
 		// if the type was not passed
-		return newChecksumFromValue(v, filepath.Base(u.EscapedPath()))
+		checksum, err := newChecksumFromValue(v, filepath.Base(u.EscapedPath()))
+		return "", checksum, err
 	}
 
 	checksumType, checksumValue := vs[0], vs[1]
 
 	switch checksumType {
 	case "file":
-		return c.ChecksumFromFile(checksumValue, u)
+		return checksumValue, nil, nil
 	default:
-		return newChecksumFromType(checksumType, checksumValue, filepath.Base(u.EscapedPath()))
+		checksum, err := newChecksumFromType(checksumType, checksumValue, filepath.Base(u.EscapedPath()))
+		return "", checksum, err
 	}
 }
 
@@ -211,6 +228,7 @@ func (c *Client) ChecksumFromFile(checksumFile string, src *url.URL) (*FileCheck
 
 	c2 := &Client{
 		Ctx:              c.Ctx,
+		RateLimiter:      c.RateLimiter,
 		Getters:          c.Getters,
 		Decompressors:    c.Decompressors,
 		Detectors:        c.Detectors,
@@ -9,10 +9,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
-
-	urlhelper "github.com/hashicorp/go-getter/helper/url"
 )
 
 // ErrSymlinkCopy means that a copy of a symlink was encountered on a request with DisableSymlinks enabled.
@@ -57,7 +53,9 @@ type Client struct {
 	Decompressors map[string]Decompressor
 
 	// Getters is the map of protocols supported by this client. If this
-	// is nil, then the default Getters variable will be used.
+	// is nil, then the default Getters variable will be used. The client
+	// downloads with its own copy of the getters that implement
+	// CloneableGetter, leaving the map and those getters untouched.
 	Getters map[string]Getter
 
 	// Dir, if true, tells the Client it is downloading a directory (versus
@@ -81,10 +79,66 @@ type Client struct {
 	// This is identical to tls.Config.InsecureSkipVerify.
 	Insecure bool
 
-	// Disable symlinks
+	// Disable symlinks, both when copying files and in archives, which
+	// fail to unpack if they contain any.
 	DisableSymlinks bool
 
+	// Atomic, if true, downloads into a staging directory next to Dst and
+	// only moves the download over Dst once it succeeded. A failed or
+	// cancelled download then leaves the previous content of Dst intact.
+	// Directories are replaced with two renames, so readers may briefly
+	// find Dst missing; see WithAtomic.
+	Atomic bool
+
+	// Retry is the policy used to retry downloads that fail with a
+	// RetryableError. By default downloads are not retried.
+	Retry RetryPolicy
+
+	// Observer is told about every phase of a download. By default no
+	// observer is used.
+	Observer Observer
+
+	// Concurrency is the maximum number of downloads GetAll runs at once.
+	// If this is zero, DefaultConcurrency is used.
+	Concurrency int
+
+	// DestinationFS is the filesystem Dst is written to. If this is nil,
+	// the OS filesystem is used.
+	DestinationFS WritableFS
+
+	// Lockfile, if set, records the version every source resolved to. If
+	// FrozenLockfile is true, sources are instead downloaded at the
+	// version recorded in Lockfile, atomically so that a mismatch leaves
+	// Dst as it was.
+	Lockfile       *Lockfile
+	FrozenLockfile bool
+
+	// Limits bounds what each download may write. By default nothing is
+	// limited.
+	Limits Limits
+
+	// RateLimiter, if set, limits the bandwidth of downloads. By default
+	// downloads are not limited.
+	RateLimiter RateLimiter
+
+	// Filter selects the files of directory downloads. It is combined with
+	// the "include" and "exclude" parameters of the source. By default
+	// every file is downloaded.
+	Filter *Filter
+
+	// SubdirMerge is how a subdir glob matching multiple paths is copied.
+	// By default such downloads fail.
+	SubdirMerge SubdirMerge
+
 	Options []ClientOption
+
+	// getters is the copy of Getters that Configure sets up for the
+	// client, so Getters itself is left as the caller set it.
+	getters map[string]Getter
+
+	// result is the GetResult of the download in progress, which getters
+	// fill in with what they resolved.
+	result *GetResult
 }
 
 // umask returns the effective umask for the Client, defaulting to the process umask
@@ -95,6 +149,25 @@ func (c *Client) umask() os.FileMode {
 	return c.Umask
 }
 
+// lookupGetter returns the getter of the client registered as key, from
+// the copy Configure set up if it did.
+func (c *Client) lookupGetter(key string) (Getter, bool) {
+	getters := c.getters
+	if getters == nil {
+		getters = c.Getters
+	}
+	g, ok := getters[key]
+	return g, ok
+}
+
+// destFS returns the filesystem the Client writes Dst to.
+func (c *Client) destFS() WritableFS {
+	if c == nil {
+		return OSFS{}
+	}
+	return destFS(c.DestinationFS)
+}
+
 // mode returns file mode umasked by the Client umask
 func (c *Client) mode(mode os.FileMode) os.FileMode {
 	m := mode & ^c.umask()
@@ -103,10 +176,51 @@ func (c *Client) mode(mode os.FileMode) os.FileMode {
 
 // Get downloads the configured source to the destination.
 func (c *Client) Get() error {
+	_, err := c.GetWithResult()
+	return err
+}
+
+// GetWithResult downloads the configured source to the destination, like
+// Get, and returns a GetResult describing what was fetched.
+func (c *Client) GetWithResult() (*GetResult, error) {
 	if err := c.Configure(c.Options...); err != nil {
-		return err
+		return nil, err
+	}
+
+	return c.getWithResult()
+}
+
+// getWithResult is GetWithResult for a client that is already configured.
+func (c *Client) getWithResult() (*GetResult, error) {
+	// Getters record what they resolve on the in-progress result.
+	result := new(GetResult)
+	c.result = result
+	defer func() { c.result = nil }()
+
+	var dst string
+	var err error
+	if c.atomic() {
+		dst, err = c.getAtomic(result)
+	} else {
+		dst, err = c.get(c.Dst, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Files, result.Bytes, err = countFiles(c.destFS(), dst)
+	if err != nil {
+		return nil, err
 	}
 
+	c.lockResult(result)
+	return result, nil
+}
+
+// get downloads the source to dst, filling in result along the way. It
+// returns the path of what was written, which is a file inside dst when a
+// file is downloaded in ClientModeAny.
+func (c *Client) get(dst string, result *GetResult) (string, error) {
 	// Store this locally since there are cases we swap this
 	mode := c.Mode
 	if mode == ClientModeInvalid {
@@ -117,81 +231,84 @@ func (c *Client) Get() error {
 		}
 	}
 
-	src, err := Detect(c.Src, c.Pwd, c.Detectors)
+	rs, err := c.resolve()
 	if err != nil {
-		return err
+		return "", err
+	}
+	pin, err := c.pinSource(rs)
+	if err != nil {
+		return "", err
+	}
+	result.Detector = rs.detector
+	result.Getter = rs.getterKey
+	c.observer().OnDetect(redactSource(c.Src), redactSource(rs.src), rs.detector)
+	g, u, subDir := getterContext(rs.getter), rs.u, rs.subDir
+
+	// dstFS is the filesystem of dst, which is on the OS filesystem
+	// whenever dst is swapped for a temporary path below. Each of them
+	// enforces the limits of the client on its own.
+	finalFS := c.limitedFS(c.destFS())
+	dstFS := finalFS
+
+	// Decompressors that support it extract a plain subdir of an archive
+	// themselves, rather than unpacking all of it to copy the subdir out.
+	member, subdirMember := rs.member, false
+	if subDir != "" && !hasGlob(subDir) && mode != ClientModeFile &&
+		rs.decompressor != nil && extractsMembers(rs.decompressor) {
+		member, subDir, subdirMember = filepath.ToSlash(subDir), "", true
 	}
 
-	// Determine if we have a forced protocol, i.e. "git::http://..."
-	force, src := getForcedGetter(src)
+	// getterFilter selects the files the getter and decompressor write.
+	// The filter is relative to the subdir, so with one it applies to the
+	// final copy instead.
+	getterFilter := rs.filter
 
 	// If there is a subdir component, then we download the root separately
 	// and then copy over the proper subdir.
 	var realDst string
-	dst := c.Dst
-	src, subDir := SourceDirSubdir(src)
 	if subDir != "" {
-		// Check if the subdirectory is attempting to traverse updwards, outside of
-		// the cloned repository path.
-		subDir = filepath.Clean(subDir)
-		if containsDotDot(subDir) {
-			return fmt.Errorf("subdirectory component contain path traversal out of the repository")
-		}
-		// Prevent absolute paths, remove a leading path separator from the subdirectory
-		if subDir[0] == os.PathSeparator {
-			subDir = subDir[1:]
-		}
+		getterFilter = nil
 
 		td, tdcloser, err := mkdirTemp("", "getter")
 		if err != nil {
-			return err
+			return "", err
 		}
 		defer func() { _ = tdcloser.Close() }()
 
 		realDst = dst
 		dst = td
+		dstFS = c.limitedFS(OSFS{})
 	}
 
-	u, err := urlhelper.Parse(src)
-	if err != nil {
-		return err
-	}
-	if force == "" {
-		force = u.Scheme
-	}
+	// In "any" mode, ask the getter which client mode to use unless the
+	// source is an archive. A file without a "filename" parameter is named
+	// after the name the server suggests for it, if the getter can tell
+	// it, which can also make it an archive.
+	var anyFile bool
+	var reportedName string
+	if mode == ClientModeAny && rs.decompressor == nil && !rs.sniff {
+		err = c.retry(c.Ctx, func() error {
+			mode, err = g.ClientModeContext(c.requestContext(), &GetterRequest{Client: c, URL: u})
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
 
-	g, ok := c.Getters[force]
-	if !ok {
-		return fmt.Errorf(
-			"download not supported for scheme '%s'", force)
-	}
+		if fr, ok := rs.getter.(FilenameReporter); ok && mode == ClientModeFile && u.Query().Get("filename") == "" {
+			// The name is a hint, so failing to get it is not an error.
+			reportedName, err = fr.FilenameContext(c.requestContext(), &GetterRequest{Client: c, URL: u})
+			if err != nil {
+				reportedName = ""
+			}
 
-	// We have magic query parameters that we use to signal different features
-	q := u.Query()
-
-	// Determine if we have an archive type
-	archiveV := q.Get("archive")
-	if archiveV != "" {
-		// Delete the paramter since it is a magic parameter we don't
-		// want to pass on to the Getter
-		q.Del("archive")
-		u.RawQuery = q.Encode()
-
-		// If we can parse the value as a bool and it is false, then
-		// set the archive to "-" which should never map to a decompressor
-		if b, err := strconv.ParseBool(archiveV); err == nil && !b {
-			archiveV = "-"
-		}
-	}
-	if archiveV == "" {
-		// We don't appear to... but is it part of the filename?
-		matchingLen := 0
-		for k := range c.Decompressors {
-			if strings.HasSuffix(u.Path, "."+k) && len(k) > matchingLen {
-				archiveV = k
-				matchingLen = len(k)
+			// It is unpacked like an archive named by the URL would be.
+			if a := c.archiveOf(reportedName); a != "" && rs.archiveByName {
+				rs.archive, rs.decompressor = a, c.Decompressors[a]
+				mode = ClientModeAny
 			}
 		}
+		anyFile = mode == ClientModeFile
 	}
 
 	// If we have a decompressor, then we need to change the destination
@@ -199,62 +316,106 @@ func (c *Client) Get() error {
 	// real path.
 	var decompressDst string
 	var decompressDir bool
-	decompressor := c.Decompressors[archiveV]
-	if decompressor != nil {
-		// Create a temporary directory to store our archive. We delete
-		// this at the end of everything.
-		td, err := os.MkdirTemp("", "getter")
-		if err != nil {
-			return fmt.Errorf(
-				"Error creating temporary directory for archive: %w", err)
-		}
-		defer func() { _ = os.RemoveAll(td) }()
-
-		// Swap the download directory to be our temporary path and
-		// store the old values.
+	var decompressFS WritableFS
+	decompressor, archive := rs.decompressor, rs.archive
+	sd, stream := c.streamDecompressor(rs)
+	if decompressor != nil || rs.sniff {
+		// Store the old values, which the archive is unpacked into.
 		decompressDst = dst
 		decompressDir = mode != ClientModeFile
-		dst = filepath.Join(td, "archive")
+		decompressFS = dstFS
 		mode = ClientModeFile
-	}
+		result.Decompressor = archive
+
+		// Unless the archive is unpacked as it is downloaded, swap the
+		// download directory to be our temporary path.
+		if !stream {
+			// Create a temporary directory to store our archive. We delete
+			// this at the end of everything.
+			td, err := os.MkdirTemp("", "getter")
+			if err != nil {
+				return "", fmt.Errorf(
+					"Error creating temporary directory for archive: %w", err)
+			}
+			defer func() { _ = os.RemoveAll(td) }()
 
-	// Determine checksum if we have one
-	checksum, err := c.extractChecksum(u)
-	if err != nil {
-		return fmt.Errorf("invalid checksum: %w", err)
+			dst = filepath.Join(td, "archive")
+			dstFS = c.limitedFS(OSFS{})
+		}
 	}
 
-	// Delete the query parameter if we have it.
-	q.Del("checksum")
-	u.RawQuery = q.Encode()
-
-	if mode == ClientModeAny {
-		// Ask the getter which client mode to use
-		mode, err = g.ClientMode(u)
+	// Fetch the checksum file if the checksum refers to one
+	checksum := rs.checksum
+	if rs.checksumFile != "" {
+		checksum, err = c.ChecksumFromFile(rs.checksumFile, u)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("invalid checksum: %w", err)
 		}
+	}
+	if checksum != nil {
+		result.ChecksumType = checksum.Type
+	}
 
+	// anyDir is the destination directory of a file downloaded in "any"
+	// mode without a "filename" parameter and whose name the server did
+	// not suggest beforehand. It is renamed after the file name the
+	// server reports with its content, if any.
+	var anyDir string
+	if anyFile {
 		// Destination is the base name of the URL path in "any" mode when
 		// a file source is detected.
-		if mode == ClientModeFile {
-			filename := filepath.Base(u.Path)
-
-			// Determine if we have a custom file name
-			if v := q.Get("filename"); v != "" {
-				// Delete the query parameter if we have it.
-				q.Del("filename")
-				u.RawQuery = q.Encode()
+		filename := filepath.Base(u.Path)
+
+		// Determine if we have a custom file name
+		q := u.Query()
+		if v := q.Get("filename"); v != "" {
+			// Delete the query parameter if we have it.
+			u.RawQuery = removeQueryParams(u.RawQuery, "filename")
+
+			filename = v
+		} else if reportedName != "" {
+			// The name the server suggests wins over the URL.
+			filename = reportedName
+		} else {
+			anyDir = dst
+		}
 
-				filename = v
-			}
+		if containsDotDot(filename) {
+			return "", fmt.Errorf("filename query parameter contain path traversal")
+		}
 
-			if containsDotDot(filename) {
-				return fmt.Errorf("filename query parameter contain path traversal")
-			}
+		dst = filepath.Join(dst, filename)
+	}
+	result.URL = RedactURL(u)
+
+	if stream {
+		err := c.getStream(rs, u, sd, checksum, pin, result, &DecompressRequest{
+			Dst:             decompressDst,
+			Src:             result.URL,
+			Dir:             decompressDir,
+			Umask:           c.umask(),
+			FS:              decompressFS,
+			Filter:          getterFilter,
+			StripComponents: rs.strip,
+			Member:          member,
+			DisableSymlinks: c.DisableSymlinks,
+			Observer:        c.Observer,
+			Kind:            archive,
+		})
+		if err != nil {
+			return "", err
+		}
+		if subdirMember {
+			c.observer().OnSubdirCopy(member)
+		}
 
-			dst = filepath.Join(dst, filename)
+		// Swap the information back
+		dst = decompressDst
+		if !decompressDir {
+			result.Mode = ClientModeFile
+			return dst, nil
 		}
+		mode = ClientModeAny
 	}
 
 	// If we're not downloading a directory, then just download the file
@@ -262,30 +423,99 @@ func (c *Client) Get() error {
 	if mode == ClientModeFile {
 		getFile := true
 		if checksum != nil {
-			if err := checksum.checksum(dst); err == nil {
+			if err := checksum.checksum(dstFS, dst); err == nil {
 				// don't get the file if the checksum of dst is correct
 				getFile = false
+				result.ChecksumSkipped = true
 			}
 		}
 		if getFile {
-			err := g.GetFile(dst, u)
+			err := c.observeGetter(rs.getterKey, result.URL, ClientModeFile, func() error {
+				return c.retry(c.Ctx, func() error {
+					return g.GetFileContext(c.requestContext(), &GetterRequest{Client: c, Dst: dst, URL: u, FS: dstFS})
+				})
+			})
 			if err != nil {
-				return err
+				return "", err
+			}
+			if err := checkPin(pin, result); err != nil {
+				return "", err
+			}
+
+			if anyDir != "" && result.Filename != "" {
+				if containsDotDot(result.Filename) {
+					return "", fmt.Errorf("Content-Disposition filename contains path traversal")
+				}
+				if named := filepath.Join(anyDir, result.Filename); named != dst {
+					if err := dstFS.Rename(dst, named); err != nil {
+						return "", err
+					}
+					dst = named
+				}
 			}
 
 			if checksum != nil {
-				if err := checksum.checksum(dst); err != nil {
-					return err
+				err := checksum.checksum(dstFS, dst)
+				c.observer().OnChecksum(checksum.Type, err == nil)
+				if err != nil {
+					return "", err
 				}
+				result.ChecksumVerified = true
+			}
+		} else {
+			// The destination already matched the checksum
+			c.observer().OnChecksum(checksum.Type, true)
+		}
+
+		if c.Lockfile != nil {
+			result.SHA256, err = fileSHA256(dstFS, dst)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if rs.sniff {
+			// Detect the archive from the content of the download, with
+			// what the server reported about it as a hint.
+			archive, err = sniffArchive(dst, result.ContentType, result.Filename, c.Decompressors)
+			if err != nil {
+				return "", err
+			}
+			decompressor = c.Decompressors[archive]
+			result.Decompressor = archive
+
+			switch {
+			case decompressor == nil && member != "":
+				return "", fmt.Errorf("archive_member requires a tar or zip archive")
+			case decompressor == nil:
+				return c.copyUnarchived(decompressFS, decompressDst, dst, decompressDir, subDir, u, result)
+			case member != "" && !extractsMembers(decompressor):
+				return "", fmt.Errorf("archive_member requires a tar or zip archive, got %s", archive)
 			}
 		}
 
 		if decompressor != nil {
 			// We have a decompressor, so decompress the current destination
 			// into the final destination with the proper mode.
-			err := decompressor.Decompress(decompressDst, dst, decompressDir, c.umask())
+			// Unpacking is local, so it isn't rate limited.
+			err := decompress(withRateLimiter(c.Ctx, nil), decompressor, &DecompressRequest{
+				Dst:             decompressDst,
+				Src:             dst,
+				Dir:             decompressDir,
+				Umask:           c.umask(),
+				FS:              decompressFS,
+				Filter:          getterFilter,
+				StripComponents: rs.strip,
+				Member:          member,
+				DisableSymlinks: c.DisableSymlinks,
+				Observer:        c.Observer,
+				Kind:            archive,
+			})
 			if err != nil {
-				return err
+				return "", err
+			}
+			if subdirMember {
+				c.observer().OnSubdirCopy(member)
 			}
 
 			// Swap the information back
@@ -301,9 +531,11 @@ func (c *Client) Get() error {
 		// if we were unarchiving. If we're still only Get-ing a file, then
 		// we're done.
 		if mode == ClientModeFile {
-			return nil
+			result.Mode = ClientModeFile
+			return dst, nil
 		}
 	}
+	result.Mode = ClientModeDir
 
 	// If we're at this point we're either downloading a directory or we've
 	// downloaded and unarchived a directory and we're just checking subdir.
@@ -313,36 +545,39 @@ func (c *Client) Get() error {
 		// If we're getting a directory, then this is an error. You cannot
 		// checksum a directory. TODO: test
 		if checksum != nil {
-			return fmt.Errorf(
+			return "", fmt.Errorf(
 				"checksum cannot be specified for directory download")
 		}
 
 		// We're downloading a directory, which might require a bit more work
-		// if we're specifying a subdir.
-		err := g.Get(dst, u)
+		// if we're specifying a subdir. Getters like git write to the OS
+		// filesystem directly, so what they write is watched for the limits.
+		err := c.observeGetter(rs.getterKey, result.URL, ClientModeDir, func() error {
+			return c.retry(c.Ctx, func() error {
+				get := func(ctx context.Context) error {
+					return g.GetContext(ctx, &GetterRequest{Client: c, Dst: dst, URL: u, FS: dstFS, Filter: getterFilter})
+				}
+				if !isOSFS(dstFS) {
+					return get(c.requestContext())
+				}
+				return c.watchLimits(c.requestContext(), dst, get)
+			})
+		})
 		if err != nil {
 			err = fmt.Errorf("error downloading '%s': %w", RedactURL(u), err)
-			return err
+			return "", err
+		}
+		if err := checkPin(pin, result); err != nil {
+			return "", err
 		}
 	}
 
-	// If we have a subdir, copy that over
+	// If we have a subdir, copy that over, processing any globs. The
+	// download is already local, so it isn't rate limited.
 	if subDir != "" {
-		if err := os.RemoveAll(realDst); err != nil {
-			return err
-		}
-		if err := os.MkdirAll(realDst, c.mode(0755)); err != nil {
-			return err
-		}
-
-		// Process any globs
-		subDir, err := SubdirGlob(dst, subDir)
-		if err != nil {
-			return err
-		}
-
-		return copyDir(c.Ctx, realDst, subDir, false, c.DisableSymlinks, c.umask())
+		ctx := withRateLimiter(c.Ctx, nil)
+		return realDst, c.copySubdir(ctx, finalFS, realDst, dst, subDir, rs.filter)
 	}
 
-	return nil
+	return dst, nil
 }
@@ -0,0 +1,245 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of downloads GetAll runs at once when
+// Client.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// Request is a single download of a batch passed to Client.GetAll.
+type Request struct {
+	// Src and Dst are the source to get and the path to save it as, like
+	// Client.Src and Client.Dst.
+	Src string
+	Dst string
+
+	// Mode is the method of download. If this is ClientModeInvalid, the
+	// mode of the client is used.
+	Mode ClientMode
+}
+
+// BatchProgress describes the overall progress of a Client.GetAll batch.
+type BatchProgress struct {
+	// Request is the request that started or finished.
+	Request Request
+
+	// Done is false when Request started and true once it finished. Err
+	// is the error it failed with, if any.
+	Done bool
+	Err  error
+
+	// Completed is the number of requests that finished so far, Failed
+	// the number of those that failed and Total the number of requests
+	// in the batch once duplicates were removed.
+	Completed int
+	Failed    int
+	Total     int
+}
+
+// BatchProgressTracker can be implemented by the ProgressListener of a
+// client to also track the overall progress of Client.GetAll. Calls to
+// TrackBatch are never made concurrently.
+type BatchProgressTracker interface {
+	ProgressTracker
+
+	// TrackBatch is called when a request of the batch starts and when
+	// it finishes.
+	TrackBatch(progress BatchProgress)
+}
+
+// RequestError is a failed request of a Client.GetAll batch.
+type RequestError struct {
+	Request Request
+	Err     error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("error downloading '%s': %s", e.Request.Src, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by Client.GetAll when one or more requests
+// failed. Errors are in the order the requests were given.
+type BatchError struct {
+	Errors []*RequestError
+}
+
+func (e *BatchError) Error() string {
+	points := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		points[i] = "* " + err.Error()
+	}
+	return fmt.Sprintf("%d download(s) failed:\n\t%s", len(e.Errors), strings.Join(points, "\n\t"))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// GetAll downloads all of the requests, running up to Concurrency of them
+// at once. Requests with the same source, destination and mode are only
+// downloaded once, and requests for a destination that an earlier request
+// with a different source or mode already uses fail without running. The
+// Src, Dst and Mode of the client are ignored and every other setting
+// applies to all requests. The client itself is left unchanged: the batch
+// runs on a configured copy of it.
+//
+// GetAll waits for every request to finish, even if some failed. If any
+// did, it returns a *BatchError naming each failing source.
+func (c *Client) GetAll(ctx context.Context, reqs []Request) error {
+	// The batch runs on a configured copy of the client, leaving the
+	// fields of c as they were.
+	bc := *c
+	bc.Options = append([]ClientOption(nil), c.Options...)
+	if ctx != nil {
+		bc.Ctx = ctx
+	}
+	if err := bc.Configure(bc.Options...); err != nil {
+		return err
+	}
+	ctx = bc.Ctx
+
+	// Requests for the same destination must be the same download, as
+	// running different ones at once would mix their files. Later
+	// requests that differ from the first one fail without running.
+	type batchKey struct {
+		src, dst string
+		mode     ClientMode
+	}
+	owners := make(map[string]Request, len(reqs))
+	seen := make(map[batchKey]bool, len(reqs))
+	unique := make([]Request, 0, len(reqs))
+	var conflicts []error
+	for _, req := range reqs {
+		mode := req.Mode
+		if mode == ClientModeInvalid {
+			mode = bc.Mode
+		}
+		dst := filepath.Clean(req.Dst)
+		k := batchKey{req.Src, dst, mode}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		var conflict error
+		if owner, ok := owners[dst]; ok {
+			conflict = fmt.Errorf("destination %s is also the destination of '%s'", req.Dst, owner.Src)
+		} else {
+			owners[dst] = req
+		}
+		unique = append(unique, req)
+		conflicts = append(conflicts, conflict)
+	}
+
+	n := bc.Concurrency
+	if n < 1 {
+		n = DefaultConcurrency
+	}
+
+	tracker, _ := bc.ProgressListener.(BatchProgressTracker)
+	var (
+		mu       sync.Mutex
+		progress = BatchProgress{Total: len(unique)}
+	)
+	track := func(req Request, done bool, err error) {
+		if tracker == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		progress.Request = req
+		progress.Done = done
+		progress.Err = err
+		if done {
+			progress.Completed++
+			if err != nil {
+				progress.Failed++
+			}
+		}
+		tracker.TrackBatch(progress)
+	}
+
+	errs := make([]error, len(unique))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, req := range unique {
+		if conflicts[i] != nil {
+			errs[i] = conflicts[i]
+			track(req, true, errs[i])
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			track(req, true, errs[i])
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			track(req, false, nil)
+			errs[i] = bc.getRequest(req)
+			track(req, true, errs[i])
+		}(i, req)
+	}
+	wg.Wait()
+
+	var batchErr BatchError
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Errors = append(batchErr.Errors, &RequestError{Request: unique[i], Err: err})
+		}
+	}
+	if len(batchErr.Errors) > 0 {
+		return &batchErr
+	}
+	return nil
+}
+
+// getRequest downloads a single request of a batch using a copy of the
+// already configured client.
+func (c *Client) getRequest(req Request) error {
+	rc := *c
+	rc.Options = append([]ClientOption(nil), c.Options...)
+	rc.result = nil
+	rc.Src = req.Src
+	rc.Dst = req.Dst
+	if req.Mode != ClientModeInvalid {
+		rc.Mode = req.Mode
+	}
+
+	// Give the request its own getters where possible. Getters that can't
+	// be cloned keep using the batch client, as calling SetClient on them
+	// concurrently would race.
+	rc.getters = cloneGetters(c.getters)
+	for k, g := range rc.getters {
+		if g != c.getters[k] {
+			g.SetClient(&rc)
+		}
+	}
+
+	_, err := rc.getWithResult()
+	return err
+}
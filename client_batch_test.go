@@ -0,0 +1,223 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_GetAll(t *testing.T) {
+	var hits int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			atomic.AddInt32(&hits, 1)
+		}
+		_, _ = rw.Write([]byte(req.URL.Path))
+	}))
+	defer s.Close()
+
+	td := t.TempDir()
+	reqs := []Request{
+		{Src: s.URL + "/a", Dst: filepath.Join(td, "a")},
+		{Src: s.URL + "/b", Dst: filepath.Join(td, "b")},
+		{Src: s.URL + "/a", Dst: filepath.Join(td, "a")},
+		{Src: s.URL + "/a", Dst: filepath.Join(td, "c")},
+	}
+
+	client := &Client{
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithConcurrency(2)},
+	}
+	if err := client.GetAll(context.Background(), reqs); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if hits := atomic.LoadInt32(&hits); hits != 3 {
+		t.Fatalf("expected 3 downloads, got %d", hits)
+	}
+	assertContents(t, filepath.Join(td, "a"), "/a")
+	assertContents(t, filepath.Join(td, "b"), "/b")
+	assertContents(t, filepath.Join(td, "c"), "/a")
+}
+
+func TestClient_GetAll_unchanged(t *testing.T) {
+	getters := map[string]Getter{"file": new(FileGetter)}
+	opts := []ClientOption{WithUmask(0022)}
+	client := &Client{Getters: getters, Options: opts}
+
+	td := t.TempDir()
+	err := client.GetAll(context.Background(), []Request{
+		{Src: testModule("basic"), Dst: filepath.Join(td, "basic"), Mode: ClientModeDir},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if client.Ctx != nil {
+		t.Fatal("expected the client context to be left unset")
+	}
+	if client.Umask != 0 || client.Decompressors != nil || client.Detectors != nil {
+		t.Fatal("expected the client options not to be applied to the client")
+	}
+	if len(client.Getters) != 1 || client.Getters["file"] != getters["file"] {
+		t.Fatal("expected the client getters to be left as they were")
+	}
+}
+
+func TestClient_GetAll_rateLimit(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	// The limiter applies to the context given to GetAll.
+	l := &countingLimiter{}
+	client := &Client{
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithRateLimiter(l)},
+	}
+	td := t.TempDir()
+	err := client.GetAll(context.Background(), []Request{
+		{Src: s.URL + "/a", Dst: filepath.Join(td, "a")},
+		{Src: s.URL + "/b", Dst: filepath.Join(td, "b")},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if l.bytes != 10 {
+		t.Fatalf("expected 10 bytes to be limited, got %d", l.bytes)
+	}
+}
+
+func TestClient_GetAll_errors(t *testing.T) {
+	td := t.TempDir()
+	reqs := []Request{
+		{Src: testModule("basic"), Dst: filepath.Join(td, "basic"), Mode: ClientModeDir},
+		{Src: testModule("nope"), Dst: filepath.Join(td, "nope")},
+		{Src: "nope://example.com/foo", Dst: filepath.Join(td, "scheme")},
+	}
+
+	client := &Client{Mode: ClientModeDir}
+	err := client.GetAll(context.Background(), reqs)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if len(batchErr.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %s", len(batchErr.Errors), err)
+	}
+	if got := batchErr.Errors[0].Request.Src; got != reqs[1].Src {
+		t.Fatalf("bad first failing source: %s", got)
+	}
+	if got := batchErr.Errors[1].Request.Src; got != reqs[2].Src {
+		t.Fatalf("bad second failing source: %s", got)
+	}
+	for _, req := range reqs[1:] {
+		if !strings.Contains(err.Error(), req.Src) {
+			t.Fatalf("error should name %s: %s", req.Src, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(td, "basic", "main.tf")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestClient_GetAll_sameDst(t *testing.T) {
+	var hits int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			atomic.AddInt32(&hits, 1)
+		}
+		_, _ = rw.Write([]byte(req.URL.Path))
+	}))
+	defer s.Close()
+
+	td := t.TempDir()
+	dst := filepath.Join(td, "a")
+	reqs := []Request{
+		{Src: s.URL + "/a", Dst: dst},
+		{Src: s.URL + "/b", Dst: dst},
+		{Src: s.URL + "/a", Dst: dst + "/", Mode: ClientModeAny},
+		{Src: s.URL + "/a", Dst: dst, Mode: ClientModeFile},
+	}
+
+	client := &Client{Mode: ClientModeFile}
+	err := client.GetAll(context.Background(), reqs)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if len(batchErr.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %s", len(batchErr.Errors), err)
+	}
+	if got := batchErr.Errors[0].Request; got != reqs[1] {
+		t.Fatalf("bad first failing request: %#v", got)
+	}
+	if got := batchErr.Errors[1].Request; got != reqs[2] {
+		t.Fatalf("bad second failing request: %#v", got)
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Fatalf("expected 1 download, got %d", hits)
+	}
+	assertContents(t, dst, "/a")
+}
+
+type mockBatchProgress struct {
+	MockProgressTracking
+
+	mu     sync.Mutex
+	events []BatchProgress
+}
+
+func (p *mockBatchProgress) TrackBatch(progress BatchProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, progress)
+}
+
+func TestClient_GetAll_progress(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(rw, "hello")
+	}))
+	defer s.Close()
+
+	td := t.TempDir()
+	reqs := []Request{
+		{Src: s.URL + "/a", Dst: filepath.Join(td, "a")},
+		{Src: s.URL + "/b", Dst: filepath.Join(td, "b")},
+		{Src: testModule("nope"), Dst: filepath.Join(td, "c")},
+	}
+
+	p := &mockBatchProgress{}
+	client := &Client{
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithProgress(p)},
+	}
+	if err := client.GetAll(context.Background(), reqs); err == nil {
+		t.Fatal("should error")
+	}
+
+	if len(p.events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(p.events))
+	}
+	last := p.events[len(p.events)-1]
+	if last.Completed != 3 || last.Failed != 1 || last.Total != 3 {
+		t.Fatalf("bad final progress: %#v", last)
+	}
+	if p.downloaded["a"] != 1 || p.downloaded["b"] != 1 {
+		t.Fatalf("expected per-item progress, got %v", p.downloaded)
+	}
+}
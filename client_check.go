@@ -0,0 +1,96 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// CheckResult is the outcome of Client.Check.
+type CheckResult struct {
+	// Changed is true if the source changed since the previous state, or
+	// if there was no previous state.
+	Changed bool
+
+	// State identifies the current content of the source. It is opaque and
+	// is meant to be stored and passed to the next call of Check.
+	State string
+}
+
+// Checker is implemented by getters that can tell whether a source changed
+// without downloading it.
+type Checker interface {
+	// CheckContext reports whether the source at req.URL changed since it
+	// was in the previous state, which is empty on the first check. Dst is
+	// empty.
+	CheckContext(ctx context.Context, req *GetterRequest, previous string) (*CheckResult, error)
+}
+
+// Check reports whether the source src changed since previousState, which
+// is the State of the CheckResult of an earlier call, or empty. It is much
+// cheaper than a download: HTTP sources are checked with a HEAD request,
+// git and Mercurial sources by resolving their ref remotely, S3 and GCS
+// sources by their ETags and generations, and local files by their
+// modification times and content.
+//
+// The source is detected and resolved like for Get, so forced getters and
+// credentials are supported. Subdirectories and archives are ignored: the
+// state is the one of the whole source. The Src, Dst and Mode of the
+// client are ignored.
+func (c *Client) Check(ctx context.Context, src, previousState string) (*CheckResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cc := *c
+	cc.Src = src
+	cc.Ctx = ctx
+	if err := cc.Configure(cc.Options...); err != nil {
+		return nil, err
+	}
+	ctx = cc.requestContext()
+
+	rs, err := cc.resolve()
+	if err != nil {
+		return nil, err
+	}
+	cc.observer().OnDetect(redactSource(cc.Src), redactSource(rs.src), rs.detector)
+
+	checker, ok := rs.getter.(Checker)
+	if !ok {
+		return nil, fmt.Errorf("getter %q cannot check sources for changes", rs.getterKey)
+	}
+
+	var result *CheckResult
+	err = cc.retry(ctx, func() error {
+		result, err = checker.CheckContext(ctx, &GetterRequest{Client: &cc, URL: rs.u}, previousState)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// newCheckResult returns the CheckResult of a source whose state changes
+// exactly when its content does.
+func newCheckResult(previous, state string) *CheckResult {
+	return &CheckResult{Changed: state != previous, State: state}
+}
+
+// listingState returns the state of a listing of objects, given the
+// identity of every object, such as its key and ETag.
+func listingState(objects []string) string {
+	sort.Strings(objects)
+
+	h := sha256.New()
+	for _, o := range objects {
+		_, _ = h.Write([]byte(o))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,171 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testCheck(t *testing.T, c *Client, src, previous string) *CheckResult {
+	t.Helper()
+
+	result, err := c.Check(context.Background(), src, previous)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return result
+}
+
+func TestClient_Check_http(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	src := fmt.Sprintf("http://%s/etag", ln.Addr())
+	result := testCheck(t, &Client{}, src, "")
+	if !result.Changed || result.State != `"hello-etag"` {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if result := testCheck(t, &Client{}, src, result.State); result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if result := testCheck(t, &Client{}, src, `"old-etag"`); !result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+
+	// Without validators the state can't be known
+	if _, err := (&Client{}).Check(context.Background(), fmt.Sprintf("http://%s/file", ln.Addr()), ""); err == nil {
+		t.Fatal("expected an error without an ETag or Last-Modified header")
+	}
+
+	_, err := (&Client{}).Check(context.Background(), fmt.Sprintf("http://%s/missing", ln.Addr()), "")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Check_git(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "check")
+	repo.commitFile("foo.txt", "hello")
+	repo.git("tag", "-a", "v1.0", "-m", "v1.0")
+	tagged, err := repo.latestCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.commitFile("bar.txt", "world")
+	latest, err := repo.latestCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "git::" + repo.url.String()
+	result := testCheck(t, &Client{}, src, "")
+	if !result.Changed || result.State != latest {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if result := testCheck(t, &Client{}, src, latest); result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+
+	// Annotated tags resolve to their commit
+	if result := testCheck(t, &Client{}, src+"?ref=v1.0", tagged); result.Changed || result.State != tagged {
+		t.Fatalf("bad result: %#v", result)
+	}
+
+	repo.commitFile("baz.txt", "!")
+	if result := testCheck(t, &Client{}, src, latest); !result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+
+	_, err = (&Client{}).Check(context.Background(), src+"?ref=missing", "")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Check_file(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := fmtFileURL(dir)
+	result := testCheck(t, &Client{}, src, "")
+	if !result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+	state := result.State
+	if result := testCheck(t, &Client{}, src, state); result.Changed || result.State != state {
+		t.Fatalf("bad result: %#v", result)
+	}
+
+	// A new modification time alone is not a change
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	result = testCheck(t, &Client{}, src, state)
+	if result.Changed || result.State == state {
+		t.Fatalf("bad result: %#v", result)
+	}
+	state = result.State
+
+	if err := os.WriteFile(path, []byte("world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result := testCheck(t, &Client{}, src, state); !result.Changed {
+		t.Fatalf("bad result: %#v", result)
+	}
+}
+
+func TestClient_Check_unsupported(t *testing.T) {
+	c := &Client{
+		Getters: map[string]Getter{"mock": new(MockGetter)},
+	}
+	if _, err := c.Check(context.Background(), "mock::foo", ""); err == nil {
+		t.Fatal("expected an error for a getter that can't check sources")
+	}
+}
+
+// checkGetter is a MockGetter that implements Checker, recording the
+// context it was called with.
+type checkGetter struct {
+	MockGetter
+
+	ctx context.Context
+}
+
+func (g *checkGetter) CheckContext(ctx context.Context, req *GetterRequest, previous string) (*CheckResult, error) {
+	g.ctx = ctx
+	return newCheckResult(previous, "state"), nil
+}
+
+func TestClient_Check_context(t *testing.T) {
+	g := new(checkGetter)
+	l := &countingLimiter{}
+	c := &Client{
+		Getters: map[string]Getter{"mock": g},
+		Options: []ClientOption{WithRateLimiter(l)},
+	}
+	//nolint:staticcheck // A nil context defaults to the background one.
+	if _, err := c.Check(nil, "mock::http://example.com/foo", ""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if g.ctx == nil {
+		t.Fatal("the getter should get a context")
+	}
+	if rateLimiterFromContext(g.ctx) != l {
+		t.Fatal("the rate limiter should be attached to the context")
+	}
+}
@@ -11,8 +11,10 @@ const (
 
 	// ClientModeAny downloads anything it can. In this mode, dst must
 	// be a directory. If src is a file, it is saved into the directory
-	// with the basename of the URL. If src is a directory or archive,
-	// it is unpacked directly into dst.
+	// with the file name the server reports, such as the HTTP
+	// Content-Disposition one, or else the basename of the URL. If src is
+	// a directory or archive, as told by the URL or the reported file
+	// name, it is unpacked directly into dst.
 	ClientModeAny
 
 	// ClientModeFile downloads a single file. In this mode, dst must
@@ -0,0 +1,250 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Metadata describes a file opened with Client.Open.
+type Metadata struct {
+	// Size is the size in bytes of the content, or -1 if it is unknown.
+	Size int64
+
+	// ContentType is the media type of the content, if known.
+	ContentType string
+
+	// Name is the name of the file. Unless the getter knows better, it
+	// is the base name of the URL path.
+	Name string
+}
+
+// Opener is implemented by getters that can stream a single file instead
+// of writing it to disk. Client.Open falls back to downloading the file
+// to a temporary directory for getters that don't implement it.
+type Opener interface {
+	// OpenContext opens the file at req.URL for reading. The returned
+	// reader must be closed by the caller. Dst is empty.
+	OpenContext(ctx context.Context, req *GetterRequest) (io.ReadCloser, Metadata, error)
+}
+
+// Open opens the file referenced by src for reading, instead of downloading
+// it to disk. The source is detected and resolved like for Get in
+// ClientModeFile, so forced getters and credentials are supported, but it
+// cannot have a subdirectory. The Src, Dst and Mode of the client are
+// ignored.
+//
+// If the source has a checksum, it is computed as the content is read and
+// a mismatch is returned as a *ChecksumError by the read reaching the end
+// of the content. Sources compressed with a ReaderDecompressor, like gz,
+// bz2, xz and zst, are decompressed on the fly; other archives can't be
+// opened. ctx cancels reading, and defaults to context.Background if nil.
+func (c *Client) Open(ctx context.Context, src string) (io.ReadCloser, Metadata, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	oc := *c
+	oc.Src = src
+	oc.Mode = ClientModeFile
+	oc.Ctx = ctx
+	if err := oc.Configure(oc.Options...); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return oc.open()
+}
+
+// open is Open for a copy of the client that is configured for src.
+func (c *Client) open() (io.ReadCloser, Metadata, error) {
+	rs, err := c.resolve()
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	c.observer().OnDetect(redactSource(c.Src), redactSource(rs.src), rs.detector)
+	u := rs.u
+
+	if rs.subDir != "" {
+		return nil, Metadata{}, fmt.Errorf("cannot open a subdirectory of %s", RedactURL(u))
+	}
+
+	if rs.sniff {
+		return nil, Metadata{}, fmt.Errorf("archive %q cannot be opened as a stream", rs.archive)
+	}
+
+	var rd ReaderDecompressor
+	if rs.decompressor != nil {
+		var ok bool
+		if rd, ok = rs.decompressor.(ReaderDecompressor); !ok {
+			return nil, Metadata{}, fmt.Errorf("archive %q cannot be opened as a stream", rs.archive)
+		}
+	}
+
+	// Fetch the checksum file if the checksum refers to one
+	checksum := rs.checksum
+	if rs.checksumFile != "" {
+		checksum, err = c.ChecksumFromFile(rs.checksumFile, u)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("invalid checksum: %w", err)
+		}
+	}
+
+	var body io.ReadCloser
+	var md Metadata
+	err = c.retry(c.Ctx, func() error {
+		gr := &GetterRequest{Client: c, URL: u}
+		if o, ok := rs.getter.(Opener); ok {
+			body, md, err = o.OpenContext(c.requestContext(), gr)
+		} else {
+			body, md, err = openDownload(c.requestContext(), getterContext(rs.getter), gr)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	if md.Name == "" {
+		md.Name = filepath.Base(u.Path)
+	}
+
+	if c.RateLimiter != nil {
+		body = &rateLimitedReader{ReadCloser: body, ctx: c.Ctx, limiter: c.RateLimiter}
+	}
+
+	if c.ProgressListener != nil {
+		body = c.ProgressListener.TrackProgress(md.Name, 0, max(md.Size, 0), body)
+	}
+
+	if checksum != nil {
+		checksum.Hash.Reset()
+		body = &checksumReader{
+			ReadCloser: body,
+			checksum:   checksum,
+			file:       RedactURL(u),
+			observer:   c.observer(),
+		}
+	}
+
+	if rd != nil {
+		dr, err := rd.DecompressReader(body)
+		if err != nil {
+			_ = body.Close()
+			return nil, Metadata{}, err
+		}
+		body = &decompressReader{ReadCloser: dr, src: body}
+		md.Size = -1
+		md.ContentType = ""
+		md.Name = strings.TrimSuffix(md.Name, "."+rs.archive)
+	}
+
+	return body, md, nil
+}
+
+// openDownload opens a file through a getter that can't stream it, by
+// downloading it to a temporary directory that is removed on Close.
+func openDownload(ctx context.Context, g GetterContext, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	td, err := os.MkdirTemp("", "getter")
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	dst := filepath.Join(td, "file")
+	err = g.GetFileContext(ctx, &GetterRequest{Client: gr.Client, Dst: dst, URL: gr.URL})
+	if err != nil {
+		_ = os.RemoveAll(td)
+		return nil, Metadata{}, err
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		_ = os.RemoveAll(td)
+		return nil, Metadata{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		_ = os.RemoveAll(td)
+		return nil, Metadata{}, err
+	}
+
+	md := Metadata{
+		Size:        fi.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(gr.URL.Path)),
+	}
+	return &limitedWrappedReaderCloser{
+		underlying: f,
+		closeFn: func() error {
+			err := f.Close()
+			_ = os.RemoveAll(td)
+			return err
+		},
+	}, md, nil
+}
+
+// checksumReader hashes the content read through it and verifies the
+// checksum once it reaches the end of the content.
+type checksumReader struct {
+	io.ReadCloser
+	checksum *FileChecksum
+	file     string
+	observer Observer
+	err      error
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.checksum.Hash.Write(p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+
+	if actual := r.checksum.Hash.Sum(nil); !bytes.Equal(actual, r.checksum.Value) {
+		r.err = &ChecksumError{
+			Hash:     r.checksum.Hash,
+			Actual:   actual,
+			Expected: r.checksum.Value,
+			File:     r.file,
+		}
+	} else {
+		r.err = io.EOF
+	}
+	r.observer.OnChecksum(r.checksum.Type, r.err == io.EOF)
+	return n, r.err
+}
+
+// decompressReader reads the decompressed content of src. Once it reaches
+// the end of that content, it reads whatever is left of src so that a
+// checksum of src is still verified.
+type decompressReader struct {
+	io.ReadCloser
+	src io.ReadCloser
+}
+
+func (r *decompressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == io.EOF {
+		if _, err := io.Copy(io.Discard, r.src); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (r *decompressReader) Close() error {
+	err := r.ReadCloser.Close()
+	if srcErr := r.src.Close(); err == nil {
+		err = srcErr
+	}
+	return err
+}
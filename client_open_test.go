@@ -0,0 +1,173 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func testOpen(t *testing.T, c *Client, src string) (string, Metadata, error) {
+	t.Helper()
+
+	rc, md, err := c.Open(context.Background(), src)
+	if err != nil {
+		return "", md, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	b, err := io.ReadAll(rc)
+	return string(b), md, err
+}
+
+func TestClient_Open_file(t *testing.T) {
+	src := testModule("basic-file/foo.txt?checksum=sha256:66a045b452102c59d840ec097d59d9467e13a3f34f6494e539ffd32c1bb35f18")
+	content, md, err := testOpen(t, &Client{}, src)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if content != "Hello\n" {
+		t.Fatalf("bad content: %q", content)
+	}
+	if md.Size != 6 || md.Name != "foo.txt" || md.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("bad metadata: %#v", md)
+	}
+}
+
+func TestClient_Open_nilContext(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	//nolint:staticcheck // A nil context defaults to the background one.
+	rc, _, err := (&Client{}).Open(nil, fmt.Sprintf("http://%s/file", ln.Addr()))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(b) != "Hello\n" {
+		t.Fatalf("bad content: %q", b)
+	}
+}
+
+func TestClient_Open_checksumMismatch(t *testing.T) {
+	o := &recordingObserver{}
+	src := testModule("basic-file/foo.txt?checksum=md5:fbd90037dacc4b1ab40811d610dde2f0")
+	_, _, err := testOpen(t, &Client{Observer: o}, src)
+
+	var cerr *ChecksumError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if !slices.Contains(o.events, "checksum md5 false") {
+		t.Fatalf("bad events: %v", o.events)
+	}
+}
+
+func TestClient_Open_decompress(t *testing.T) {
+	for _, ext := range []string{"gz", "bz2", "xz", "zst"} {
+		t.Run(ext, func(t *testing.T) {
+			src := testModule(fmt.Sprintf("decompress-%s/single.%s", ext, ext))
+			content, md, err := testOpen(t, &Client{}, src)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if content != "foo\n" {
+				t.Fatalf("bad content: %q", content)
+			}
+			if md.Size != -1 || md.Name != "single" {
+				t.Fatalf("bad metadata: %#v", md)
+			}
+		})
+	}
+}
+
+func TestClient_Open_decompressChecksum(t *testing.T) {
+	// The checksum is the one of the compressed file.
+	src := testModule("decompress-gz/single.gz?checksum=sha256:37f793280df6977e3e4b7fadbcfb71f85de3f9133f69a22c794dff43ecc16b41")
+	content, _, err := testOpen(t, &Client{}, src)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if content != "foo\n" {
+		t.Fatalf("bad content: %q", content)
+	}
+
+	src = testModule("decompress-gz/single.gz?checksum=sha256:66a045b452102c59d840ec097d59d9467e13a3f34f6494e539ffd32c1bb35f18")
+	var cerr *ChecksumError
+	if _, _, err := testOpen(t, &Client{}, src); !errors.As(err, &cerr) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+}
+
+func TestClient_Open_archive(t *testing.T) {
+	_, _, err := (&Client{}).Open(context.Background(), testModule("basic-file-archive/archive.tar.gz"))
+	if err == nil {
+		t.Fatal("expected an error for an archive")
+	}
+}
+
+func TestClient_Open_http(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	content, md, err := testOpen(t, &Client{}, fmt.Sprintf("http://%s/file", ln.Addr()))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if content != "Hello\n" {
+		t.Fatalf("bad content: %q", content)
+	}
+	if md.Size != 6 || md.Name != "file" {
+		t.Fatalf("bad metadata: %#v", md)
+	}
+
+	// The name comes from the Content-Disposition header if there is one
+	_, md, err = testOpen(t, &Client{}, fmt.Sprintf("http://%s/attachment", ln.Addr()))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if md.Name != "hello.txt" {
+		t.Fatalf("bad name: %q", md.Name)
+	}
+
+	_, _, err = testOpen(t, &Client{}, fmt.Sprintf("http://%s/missing", ln.Addr()))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Open_fallback(t *testing.T) {
+	g := &MockGetter{Proxy: &FileGetter{Copy: true}}
+	c := &Client{
+		Getters: map[string]Getter{"mock": g},
+	}
+
+	u, err := url.Parse(testModule("basic-file/foo.txt"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	content, md, err := testOpen(t, c, "mock::"+u.String())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !g.GetFileCalled {
+		t.Fatal("GetFile should be called")
+	}
+	if content != "Hello\n" {
+		t.Fatalf("bad content: %q", content)
+	}
+	if md.Size != 6 || md.Name != "foo.txt" {
+		t.Fatalf("bad metadata: %#v", md)
+	}
+}
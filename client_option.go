@@ -43,13 +43,22 @@ func (c *Client) Configure(opts ...ClientOption) error {
 		c.Getters = Getters
 	}
 
+	c.setGetters()
+
+	return nil
+}
+
+// setGetters gives the client its own copy of its Getters, so concurrent
+// clients sharing a Getters map don't overwrite each other's settings.
+// Getters itself is left untouched.
+func (c *Client) setGetters() {
+	c.getters = cloneGetters(c.Getters)
+
 	// Set the client for each getter, so the top-level client can know
 	// the getter-specific client functions or progress tracking.
-	for _, getter := range c.Getters {
+	for _, getter := range c.getters {
 		getter.SetClient(c)
 	}
-
-	return nil
 }
 
 // WithContext allows to pass a context to operation
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// WithAtomic makes the client download into a staging directory next to
+// Dst and only move the download over Dst once it succeeded, so a failed
+// or cancelled download never leaves Dst half-written.
+//
+// Files are replaced with a single rename. Replacing a directory takes two
+// renames, moving Dst aside and the download into its place, so it is not
+// atomic: a concurrent reader may briefly find Dst missing.
+//
+// Since the staging directory starts out empty, an atomic download always
+// fetches the whole source again rather than updating what is in Dst.
+func WithAtomic() func(*Client) error {
+	return func(c *Client) error {
+		c.Atomic = true
+		return nil
+	}
+}
+
+// atomic reports whether the client downloads into a staging directory.
+// Downloads checked against a frozen lockfile always are, so content that
+// doesn't match it never replaces Dst.
+func (c *Client) atomic() bool {
+	return c.Atomic || (c.Lockfile != nil && c.FrozenLockfile)
+}
+
+// getAtomic downloads the source into a staging directory created next to
+// Dst, so that it lives on the same filesystem, and then moves it over Dst.
+func (c *Client) getAtomic(result *GetResult) (string, error) {
+	fsys := c.destFS()
+	dst := filepath.Clean(c.Dst)
+	parent := filepath.Dir(dst)
+	if err := fsys.MkdirAll(parent, c.mode(0755)); err != nil {
+		return "", err
+	}
+
+	staging, err := mkdirTempFS(fsys, parent, "."+filepath.Base(dst)+".getter-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = fsys.RemoveAll(staging) }()
+
+	stagingDst := filepath.Join(staging, filepath.Base(dst))
+	path, err := c.get(stagingDst, result)
+	if err != nil {
+		return "", err
+	}
+
+	// A file downloaded in ClientModeAny is written inside the destination,
+	// in which case only that file is moved into Dst.
+	rel, err := filepath.Rel(stagingDst, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		if err := replacePath(fsys, stagingDst, dst); err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+
+	target := filepath.Join(dst, rel)
+	if err := fsys.MkdirAll(filepath.Dir(target), c.mode(0755)); err != nil {
+		return "", err
+	}
+	if err := replacePath(fsys, path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// replacePath renames src over dst on fsys. Files are replaced with a
+// single rename. Otherwise an existing dst is first moved aside, restored
+// if the rename fails and removed once it succeeded.
+func replacePath(fsys WritableFS, src, dst string) error {
+	srcFi, err := fsys.Lstat(src)
+	if err != nil {
+		return err
+	}
+	dstFi, err := fsys.Lstat(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fsys.Rename(src, dst)
+	}
+	if err != nil {
+		return err
+	}
+	if srcFi.Mode().IsRegular() && dstFi.Mode().IsRegular() {
+		return fsys.Rename(src, dst)
+	}
+
+	backupDir, err := mkdirTempFS(fsys, filepath.Dir(dst), "."+filepath.Base(dst)+".getter-old-")
+	if err != nil {
+		return err
+	}
+	backup := filepath.Join(backupDir, filepath.Base(dst))
+	if err := fsys.Rename(dst, backup); err != nil {
+		_ = fsys.Remove(backupDir)
+		return err
+	}
+	if err := fsys.Rename(src, dst); err != nil {
+		if rerr := fsys.Rename(backup, dst); rerr != nil {
+			return fmt.Errorf("error moving %s into place: %w; previous content left in %s", src, err, backup)
+		}
+		_ = fsys.RemoveAll(backupDir)
+		return err
+	}
+
+	// dst was replaced, so failing to clean up the backup is no error.
+	_ = fsys.RemoveAll(backupDir)
+	return nil
+}
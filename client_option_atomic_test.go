@@ -0,0 +1,154 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGet_atomicArchive(t *testing.T) {
+	parent := t.TempDir()
+	dst := filepath.Join(parent, "dst")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "old"), []byte("old"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	u := testModule("archive-rooted/archive.tar.gz")
+	if err := Get(dst, u, WithAtomic()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "old")); !os.IsNotExist(err) {
+		t.Fatalf("old content should have been replaced, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "root", "hello.txt")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertNoStaging(t, parent, "dst")
+}
+
+func TestGet_atomicFailureKeepsDst(t *testing.T) {
+	parent := t.TempDir()
+	dst := filepath.Join(parent, "dst")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	old := filepath.Join(dst, "old")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	u := testModule("archive.tar.gz") + "?checksum=md5:00000000000000000000000000000000"
+	if err := Get(dst, u, WithAtomic()); err == nil {
+		t.Fatal("should error")
+	}
+
+	assertContents(t, old, "old")
+	assertNoStaging(t, parent, "dst")
+}
+
+func TestGet_atomicCancelKeepsDst(t *testing.T) {
+	parent := t.TempDir()
+	dst := filepath.Join(parent, "dst")
+	if err := os.WriteFile(dst, []byte("old"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	httpSrv := testHttpServer(t)
+	defer httpSrv.Close()
+
+	client := &Client{
+		Ctx:     ctx,
+		Src:     "http://" + httpSrv.Addr().String() + "/file",
+		Dst:     dst,
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithAtomic()},
+	}
+	if err := client.Get(); err == nil {
+		t.Fatal("should error")
+	}
+
+	assertContents(t, dst, "old")
+	assertNoStaging(t, parent, "dst")
+}
+
+func TestGet_atomicAnyFile(t *testing.T) {
+	parent := t.TempDir()
+	dst := filepath.Join(parent, "dst")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "keep"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client := &Client{
+		Src:     testModule("basic-file/foo.txt"),
+		Dst:     dst,
+		Pwd:     ".",
+		Mode:    ClientModeAny,
+		Options: []ClientOption{WithAtomic()},
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Mode != ClientModeFile {
+		t.Fatalf("bad mode: %d", result.Mode)
+	}
+
+	assertContents(t, filepath.Join(dst, "foo.txt"), "Hello\n")
+	assertContents(t, filepath.Join(dst, "keep"), "keep")
+	assertNoStaging(t, parent, "dst")
+}
+
+// assertNoStaging checks that dir holds nothing but name, i.e. that no
+// staging or backup directory was left behind.
+func assertNoStaging(t *testing.T, dir, name string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, e := range entries {
+		if e.Name() != name {
+			t.Fatalf("unexpected entry left in %s: %s", dir, e.Name())
+		}
+	}
+}
+
+// failingRemoveFS is a memFS that can't remove anything.
+type failingRemoveFS struct {
+	*memFS
+}
+
+func (failingRemoveFS) RemoveAll(string) error { return errors.New("remove failed") }
+
+func TestReplacePath_backupCleanup(t *testing.T) {
+	m := newMemFS()
+	for _, dir := range []string{"/dst", "/staging"} {
+		if err := m.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The destination was replaced, so the leftover backup is no error.
+	if err := replacePath(failingRemoveFS{m}, "/staging", "/dst"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := m.Lstat("/staging"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the staging directory to be moved: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import "fmt"
+
+// WithConcurrency sets the maximum number of downloads Client.GetAll runs
+// at once.
+func WithConcurrency(n int) func(*Client) error {
+	return func(c *Client) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency must be at least 1, got %d", n)
+		}
+		c.Concurrency = n
+		return nil
+	}
+}
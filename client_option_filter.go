@@ -0,0 +1,31 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithFilter makes directory downloads only write the files matching the
+// include glob patterns, if any, and none of the exclude patterns. See
+// Filter for the syntax of the patterns. Sources can add patterns with the
+// "include" and "exclude" parameters.
+//
+// The S3, GCS and file getters and the tar and zip decompressors skip
+// excluded files altogether. Git and Mercurial sources are cloned into a
+// temporary directory, from which the selected files are copied.
+func WithFilter(include, exclude []string) func(*Client) error {
+	return func(c *Client) error {
+		f, err := NewFilter(include, exclude)
+		if err != nil {
+			return err
+		}
+		c.Filter = f
+		return nil
+	}
+}
+
+// withFilter sets the Filter of the client as is, for nested downloads.
+func withFilter(f *Filter) func(*Client) error {
+	return func(c *Client) error {
+		c.Filter = f
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithDestinationFS makes the client write downloads to fsys instead of
+// the OS filesystem, for example an in-memory filesystem or a sandbox.
+//
+// Git and Mercurial sources are cloned into a temporary directory on the
+// OS filesystem and copied into fsys, so they are fetched in full every
+// time rather than updated. Getters and decompressors that only implement
+// Getter and Decompressor go through a temporary directory the same way,
+// and such decompressors don't support archive_strip or archive_member.
+func WithDestinationFS(fsys WritableFS) func(*Client) error {
+	return func(c *Client) error {
+		c.DestinationFS = fsys
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithLimits bounds the number and size of the files each download may
+// write, which matters when fetching untrusted sources. A download that
+// exceeds a limit fails with a *LimitError.
+//
+// Git and Mercurial write their working trees directly, so those are
+// checked periodically while the clone runs, which stops it once a limit
+// is exceeded. Their .git and .hg metadata directories count too, unless
+// SkipVCSMetadata is set.
+func WithLimits(limits Limits) func(*Client) error {
+	return func(c *Client) error {
+		c.Limits = limits
+		return nil
+	}
+}
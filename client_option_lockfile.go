@@ -0,0 +1,34 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithLockfile makes the client record in l the version every source it
+// downloads resolved to, along with the sha256 of downloaded files.
+func WithLockfile(l *Lockfile) func(*Client) error {
+	return func(c *Client) error {
+		c.Lockfile = l
+		c.FrozenLockfile = false
+		return nil
+	}
+}
+
+// WithFrozenLockfile makes the client download every source at the version
+// recorded for it in l, and fail if the source is not in l or its content
+// no longer matches. l is not modified.
+//
+// Git, Mercurial, S3 and GCS sources are rewritten to the pinned commit,
+// changeset, VersionId or generation. Files, including the ones downloaded
+// over HTTP, are verified against their recorded sha256 unless the source
+// has its own checksum.
+//
+// Since versions and digests can only be checked once a source is
+// downloaded, downloads are always atomic, as with WithAtomic: a source
+// that doesn't match l leaves the destination as it was.
+func WithFrozenLockfile(l *Lockfile) func(*Client) error {
+	return func(c *Client) error {
+		c.Lockfile = l
+		c.FrozenLockfile = true
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import "time"
+
+// WithObserver sets an Observer that is told about every phase of a
+// download, for example to keep an audit trail or record metrics.
+func WithObserver(o Observer) func(*Client) error {
+	return func(c *Client) error {
+		c.Observer = o
+		return nil
+	}
+}
+
+// Observer receives callbacks for the phases of Client.Get. URLs passed to
+// it have their credentials redacted. The callbacks run synchronously, and
+// concurrently when the client is used by GetAll.
+//
+// Embed NopObserver to only implement some of the callbacks.
+type Observer interface {
+	// OnDetect is called once src was detected as result. detector is the
+	// detector that rewrote it, or nil if src already was a valid URL.
+	OnDetect(src, result string, detector Detector)
+
+	// OnGetterStart is called before the getter registered as getter
+	// downloads url, and OnGetterDone once it returned. mode is
+	// ClientModeFile or ClientModeDir depending on whether a file or a
+	// directory is downloaded, and duration includes any retries.
+	OnGetterStart(getter, url string, mode ClientMode)
+	OnGetterDone(err error, duration time.Duration)
+
+	// OnChecksum is called after a file was verified against a checksum of
+	// type checksumType.
+	OnChecksum(checksumType string, ok bool)
+
+	// OnDecompress is called by the decompressor registered as kind after
+	// it unpacked an archive, with the number of regular files it wrote
+	// and their total size.
+	OnDecompress(kind string, files int, bytes int64)
+
+	// OnSubdirCopy is called before the subdirectory path of a download is
+	// copied to the destination, or after the decompressor extracted it
+	// from an archive.
+	OnSubdirCopy(path string)
+
+	// OnXTerraformGetRedirect is called when an HTTP source at from points
+	// to the source to through X-Terraform-Get.
+	OnXTerraformGetRedirect(from, to string)
+}
+
+// NopObserver is an Observer that ignores every callback.
+type NopObserver struct{}
+
+func (NopObserver) OnDetect(src, result string, detector Detector)    {}
+func (NopObserver) OnGetterStart(getter, url string, mode ClientMode) {}
+func (NopObserver) OnGetterDone(err error, duration time.Duration)    {}
+func (NopObserver) OnChecksum(checksumType string, ok bool)           {}
+func (NopObserver) OnDecompress(kind string, files int, bytes int64)  {}
+func (NopObserver) OnSubdirCopy(path string)                          {}
+func (NopObserver) OnXTerraformGetRedirect(from, to string)           {}
+
+// observer returns the Observer of the client, or a NopObserver if there is
+// none.
+func (c *Client) observer() Observer {
+	if c == nil || c.Observer == nil {
+		return NopObserver{}
+	}
+	return c.Observer
+}
+
+// observeGetter runs fn, which downloads u with the getter registered as
+// key, between the OnGetterStart and OnGetterDone callbacks.
+func (c *Client) observeGetter(key, u string, mode ClientMode, fn func() error) error {
+	o := c.observer()
+	o.OnGetterStart(key, u, mode)
+	start := time.Now()
+	err := fn()
+	o.OnGetterDone(err, time.Since(start))
+	return err
+}
@@ -0,0 +1,170 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver records the callbacks it receives, leaving out values
+// that change between runs.
+type recordingObserver struct {
+	NopObserver
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(format string, args ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *recordingObserver) OnGetterStart(getter, url string, mode ClientMode) {
+	o.record("start %s %d", getter, mode)
+}
+
+func (o *recordingObserver) OnGetterDone(err error, duration time.Duration) {
+	o.record("done %v", err)
+}
+
+func (o *recordingObserver) OnChecksum(checksumType string, ok bool) {
+	o.record("checksum %s %t", checksumType, ok)
+}
+
+func (o *recordingObserver) OnDecompress(kind string, files int, bytes int64) {
+	o.record("decompress %s %d %d", kind, files, bytes)
+}
+
+func (o *recordingObserver) OnSubdirCopy(path string) {
+	o.record("subdir %s", path)
+}
+
+func (o *recordingObserver) OnXTerraformGetRedirect(from, to string) {
+	o.record("redirect %s", to)
+}
+
+func TestGet_observer(t *testing.T) {
+	archive := testModule("archive-rooted/archive.tar.gz")
+	b, err := os.ReadFile(filepath.Join(fixtureDir, "archive-rooted", "archive.tar.gz"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	sum := sha256.Sum256(b)
+
+	o := &recordingObserver{}
+	dst := filepath.Join(t.TempDir(), "dst")
+	src := archive + "//root?checksum=sha256:" + hex.EncodeToString(sum[:])
+	if err := Get(dst, src, WithObserver(o)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The archive is streamed, so it is unpacked and verified before the
+	// getter is done.
+	expected := []string{
+		"start file 2",
+		"decompress tar.gz 1 6",
+		"checksum sha256 true",
+		"done <nil>",
+		"subdir root",
+	}
+	if !reflect.DeepEqual(o.events, expected) {
+		t.Fatalf("bad events\nexpected: %q\ngot:      %q", expected, o.events)
+	}
+}
+
+func TestGet_observerDecompressExisting(t *testing.T) {
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, "existing"), []byte("existing"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Only the files of the archive are reported, while it is streamed.
+	o := &recordingObserver{}
+	src := testModule("archive-rooted/archive.tar.gz")
+	if err := Get(dst, src, WithObserver(o)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{
+		"start file 2",
+		"decompress tar.gz 1 6",
+		"done <nil>",
+	}
+	if !reflect.DeepEqual(o.events, expected) {
+		t.Fatalf("bad events\nexpected: %q\ngot:      %q", expected, o.events)
+	}
+}
+
+type detectObserver struct {
+	NopObserver
+	src, result string
+	detector    Detector
+}
+
+func (o *detectObserver) OnDetect(src, result string, detector Detector) {
+	o.src, o.result, o.detector = src, result, detector
+}
+
+func TestGet_observerDetect(t *testing.T) {
+	pwd, err := filepath.Abs(fixtureDir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	o := &detectObserver{}
+	client := &Client{
+		Src:     "./basic",
+		Dst:     filepath.Join(t.TempDir(), "dst"),
+		Pwd:     pwd,
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithObserver(o)},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if o.src != "./basic" {
+		t.Fatalf("bad src: %s", o.src)
+	}
+	if !strings.HasPrefix(o.result, "file://") || !strings.HasSuffix(o.result, "basic") {
+		t.Fatalf("bad result: %s", o.result)
+	}
+	if _, ok := o.detector.(*FileDetector); !ok {
+		t.Fatalf("bad detector: %#v", o.detector)
+	}
+}
+
+func TestGet_observerXTerraformGet(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	o := &recordingObserver{}
+	dst := filepath.Join(t.TempDir(), "dst")
+	src := "http://" + ln.Addr().String() + "/header"
+	if err := Get(dst, src, WithObserver(o)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{
+		"start http 3",
+		"redirect " + testModuleURL("basic").String(),
+		"start file 3",
+		"done <nil>",
+		"done <nil>",
+	}
+	if !reflect.DeepEqual(o.events, expected) {
+		t.Fatalf("bad events\nexpected: %q\ngot:      %q", expected, o.events)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithRateLimit limits the bandwidth the client downloads with to
+// bytesPerSecond on average, with bursts of up to burst bytes. All clients
+// configured with the same option share that bandwidth, including the
+// requests of a GetAll batch.
+//
+// The limit applies to the content streamed by the HTTP, S3, GCS and file
+// getters. Git and Mercurial run as separate processes and are not
+// limited. Configuring the client fails if bytesPerSecond is not positive.
+func WithRateLimit(bytesPerSecond int64, burst int) func(*Client) error {
+	l, err := NewRateLimiter(bytesPerSecond, burst)
+	if err != nil {
+		return func(*Client) error { return err }
+	}
+	return WithRateLimiter(l)
+}
+
+// WithRateLimiter limits the bandwidth of the client with l, which can be
+// shared by any number of clients in the process so they split its
+// budget.
+func WithRateLimiter(l RateLimiter) func(*Client) error {
+	return func(c *Client) error {
+		c.RateLimiter = l
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the client retries a download that failed with a
+// RetryableError.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	// one. Values below 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the wait before the first retry, which doubles with
+	// every further attempt up to MaxBackoff. They default to 1 second and
+	// 30 seconds respectively. MaxBackoff also caps the wait a remote asks
+	// for with a RetryableError.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// NoJitter disables the randomization of the backoff, which otherwise
+	// waits for a random duration between half and all of it.
+	NoJitter bool
+}
+
+// WithRetry makes the client retry downloads that fail with an error a
+// getter classified as transient, such as an HTTP 503 or a connection
+// reset, according to policy.
+func WithRetry(policy RetryPolicy) func(*Client) error {
+	return func(c *Client) error {
+		if policy.MinBackoff < 0 || policy.MaxBackoff < 0 {
+			return fmt.Errorf("retry backoff must not be negative")
+		}
+		c.Retry = policy
+		return nil
+	}
+}
+
+// RetryableError wraps an error that is transient, so retrying the same
+// download may succeed. Getters return it to opt errors into retries.
+type RetryableError struct {
+	Err error
+
+	// RetryAfter is how long the remote asked to wait before retrying,
+	// such as from an HTTP Retry-After header, up to the MaxBackoff of the
+	// RetryPolicy. Zero means the backoff of the RetryPolicy is used.
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// backoff returns how long to wait before the given retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	minBackoff, maxBackoff := p.MinBackoff, p.maxBackoff()
+	if minBackoff == 0 {
+		minBackoff = time.Second
+	}
+
+	d := minBackoff
+	for i := 1; i < retry && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	if !p.NoJitter && d > 1 {
+		d = d/2 + rand.N(d/2)
+	}
+	return d
+}
+
+// maxBackoff returns the longest wait before a retry.
+func (p RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff == 0 {
+		return 30 * time.Second
+	}
+	return p.MaxBackoff
+}
+
+// retry runs fn until it succeeds, fails with an error that is not a
+// RetryableError or the attempts of the client's RetryPolicy run out. It
+// stops waiting as soon as ctx is done.
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.Retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		var retryable *RetryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+
+		wait := retryable.RetryAfter
+		if wait <= 0 {
+			wait = c.Retry.backoff(attempt)
+		} else if maxWait := c.Retry.maxBackoff(); wait > maxWait {
+			wait = maxWait
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// isTransientNetError reports whether err is a network failure that may not
+// happen again, such as a timeout or a reset connection.
+func isTransientNetError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
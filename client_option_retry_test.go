@@ -0,0 +1,177 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryServer returns a server that fails GET requests with the given
+// status codes, in order, and then serves "Hello\n".
+func testRetryServer(codes ...int) (*httptest.Server, *int32) {
+	var gets int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			return
+		}
+		n := int(atomic.AddInt32(&gets, 1))
+		if n <= len(codes) {
+			rw.WriteHeader(codes[n-1])
+			return
+		}
+		_, _ = rw.Write([]byte("Hello\n"))
+	}))
+	return s, &gets
+}
+
+func TestGet_retry(t *testing.T) {
+	s, gets := testRetryServer(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	defer s.Close()
+
+	dst := filepath.Join(t.TempDir(), "file")
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	if err := GetFile(dst, s.URL+"/file", WithRetry(policy)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if n := atomic.LoadInt32(gets); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+	assertContents(t, dst, "Hello\n")
+}
+
+func TestGet_retryExhausted(t *testing.T) {
+	s, gets := testRetryServer(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	defer s.Close()
+
+	dst := filepath.Join(t.TempDir(), "file")
+	policy := RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+	err := GetFile(dst, s.URL+"/file", WithRetry(policy))
+
+	var retryable *RetryableError
+	if !errors.As(err, &retryable) {
+		t.Fatalf("expected a RetryableError, got %v", err)
+	}
+	if n := atomic.LoadInt32(gets); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestGet_retryNotFound(t *testing.T) {
+	s, gets := testRetryServer(http.StatusNotFound)
+	defer s.Close()
+
+	dst := filepath.Join(t.TempDir(), "file")
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	if err := GetFile(dst, s.URL+"/file", WithRetry(policy)); err == nil {
+		t.Fatal("should error")
+	}
+
+	if n := atomic.LoadInt32(gets); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestGet_retryContextCancel(t *testing.T) {
+	s, gets := testRetryServer(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	dst := filepath.Join(t.TempDir(), "file")
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour}
+	if err := GetFile(dst, s.URL+"/file", WithContext(ctx), WithRetry(policy)); err == nil {
+		t.Fatal("should error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("retry did not stop on cancellation, took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(gets); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestGet_retryAfterMaxBackoff(t *testing.T) {
+	var gets int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			return
+		}
+		if atomic.AddInt32(&gets, 1) == 1 {
+			rw.Header().Set("Retry-After", "3600")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte("Hello\n"))
+	}))
+	defer s.Close()
+
+	start := time.Now()
+	dst := filepath.Join(t.TempDir(), "file")
+	policy := RetryPolicy{MaxAttempts: 2, MaxBackoff: 10 * time.Millisecond}
+	if err := GetFile(dst, s.URL+"/file", WithRetry(policy)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Retry-After was not capped by MaxBackoff, took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&gets); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+	assertContents(t, dst, "Hello\n")
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second, NoJitter: true}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := p.backoff(i + 1); got != want {
+			t.Fatalf("retry %d: expected %s, got %s", i+1, want, got)
+		}
+	}
+
+	p.NoJitter = false
+	for i := 1; i < 10; i++ {
+		d := p.backoff(i)
+		if d < 500*time.Millisecond || d > 5*time.Second {
+			t.Fatalf("retry %d: backoff out of range: %s", i, d)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		Header string
+		Min    time.Duration
+		Max    time.Duration
+	}{
+		{"", 0, 0},
+		{"nope", 0, 0},
+		{"-1", 0, 0},
+		{"120", 120 * time.Second, 120 * time.Second},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 30 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+
+	for _, tc := range cases {
+		h := http.Header{}
+		if tc.Header != "" {
+			h.Set("Retry-After", tc.Header)
+		}
+		if got := retryAfter(h); got < tc.Min || got > tc.Max {
+			t.Fatalf("%q: expected between %s and %s, got %s", tc.Header, tc.Min, tc.Max, got)
+		}
+	}
+}
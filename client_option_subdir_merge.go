@@ -0,0 +1,14 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+// WithSubdirMerge lets subdir globs such as "//modules/*" or "//{a,b}"
+// match multiple paths, which are all copied into the destination as
+// merge says. Subdirs without glob patterns are unaffected.
+func WithSubdirMerge(merge SubdirMerge) func(*Client) error {
+	return func(c *Client) error {
+		c.SubdirMerge = merge
+		return nil
+	}
+}
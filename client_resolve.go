@@ -0,0 +1,293 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	urlhelper "github.com/hashicorp/go-getter/helper/url"
+)
+
+// Plan describes how Client.Get would download a source. It is returned by
+// Client.Resolve, which builds it without any network or filesystem access.
+type Plan struct {
+	// URL is the final URL that would be passed to the getter, with any
+	// credentials redacted by RedactURL.
+	URL string
+
+	// Getter is the key in Client.Getters of the getter that would be used.
+	Getter string
+
+	// Subdir is the subdirectory that would be copied out of the
+	// download, if any.
+	Subdir string
+
+	// Decompressor is the key in Client.Decompressors of the decompressor
+	// that would unpack the download. It is empty if the source is not an
+	// archive, and "auto" if it is detected from the content of the
+	// download.
+	Decompressor string
+
+	// StripComponents is the parsed "archive_strip" parameter, the number
+	// of leading directories stripped from the entries of the archive or
+	// StripAuto.
+	StripComponents int
+
+	// ArchiveMember is the "archive_member" parameter, the path of the
+	// only entry extracted from the archive.
+	ArchiveMember string
+
+	// ChecksumType and ChecksumValue are the parsed "checksum" parameter.
+	// ChecksumValue is hex encoded. When the checksum is read from a file,
+	// ChecksumType is "file" and ChecksumValue is the URL of that file,
+	// which is not fetched.
+	ChecksumType  string
+	ChecksumValue string
+
+	// Filename is the "filename" parameter, which overrides the name of a
+	// file downloaded in ClientModeAny.
+	Filename string
+
+	// Filter selects the files of a directory download. It combines the
+	// Filter of the client with the "include" and "exclude" parameters,
+	// and is nil if there are none.
+	Filter *Filter
+}
+
+// Resolve plans the download of the configured source without fetching
+// anything. It runs detection, handles forced getters, subdirectories and
+// the archive and checksum parameters and looks up the getter, returning
+// the same errors Get would raise before doing any I/O.
+func (c *Client) Resolve() (*Plan, error) {
+	if err := c.Configure(c.Options...); err != nil {
+		return nil, err
+	}
+
+	rs, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	filename := rs.u.Query().Get("filename")
+	if c.Mode == ClientModeAny && containsDotDot(filename) {
+		return nil, fmt.Errorf("filename query parameter contain path traversal")
+	}
+
+	plan := &Plan{
+		URL:             RedactURL(rs.u),
+		Getter:          rs.getterKey,
+		Subdir:          rs.subDir,
+		Decompressor:    rs.archive,
+		StripComponents: rs.strip,
+		ArchiveMember:   rs.member,
+		Filename:        filename,
+		Filter:          rs.filter,
+	}
+	switch {
+	case rs.checksumFile != "":
+		plan.ChecksumType = "file"
+		plan.ChecksumValue = rs.checksumFile
+	case rs.checksum != nil:
+		plan.ChecksumType = rs.checksum.Type
+		plan.ChecksumValue = hex.EncodeToString(rs.checksum.Value)
+	}
+
+	return plan, nil
+}
+
+// resolvedSource holds the outcome of the steps of a download that need no
+// I/O.
+type resolvedSource struct {
+	// src is the source after detection and detector the detector that
+	// rewrote it, if any.
+	src      string
+	detector Detector
+
+	// getterKey and getter are the key and value in Client.Getters of
+	// the getter to use.
+	getterKey string
+	getter    Getter
+
+	// u is the URL to pass to the getter. The "archive", "archive_strip",
+	// "archive_member", "checksum", "include" and "exclude" parameters
+	// have been removed from it.
+	u *url.URL
+
+	// subDir is the cleaned subdirectory to copy out of the download.
+	subDir string
+
+	// archive and decompressor are the key and value in
+	// Client.Decompressors of the decompressor to use, if any.
+	archive      string
+	decompressor Decompressor
+
+	// sniff is set by the "archive=auto" parameter, for which the
+	// decompressor is detected once the file is downloaded.
+	sniff bool
+
+	// archiveByName is set when there is no "archive" parameter, so the
+	// decompressor may be picked from the file name the server reports.
+	archiveByName bool
+
+	// strip is the parsed "archive_strip" parameter, a number of
+	// directories or StripAuto.
+	strip int
+
+	// member is the cleaned "archive_member" parameter, the slash
+	// separated path of the entry of the archive to extract.
+	member string
+
+	// checksum is the parsed "checksum" parameter. If it references a
+	// checksum file, checksumFile is its URL and checksum is nil.
+	checksum     *FileChecksum
+	checksumFile string
+
+	// filter is the Filter of the client combined with the "include" and
+	// "exclude" parameters.
+	filter *Filter
+}
+
+// resolve runs detection on the configured source and parses it into the
+// getter and the magic parameters that drive the download.
+func (c *Client) resolve() (*resolvedSource, error) {
+	src, detector, err := detect(c.Src, c.Pwd, c.Detectors)
+	if err != nil {
+		return nil, err
+	}
+	rs := &resolvedSource{src: src, detector: detector}
+
+	s, err := ParseSource(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// If there is a subdir component, then we download the root separately
+	// and then copy over the proper subdir.
+	if subDir := s.Subdir; subDir != "" {
+		// Check if the subdirectory is attempting to traverse updwards, outside of
+		// the cloned repository path.
+		subDir = filepath.Clean(subDir)
+		if containsDotDot(subDir) {
+			return nil, fmt.Errorf("subdirectory component contain path traversal out of the repository")
+		}
+		// Prevent absolute paths, remove a leading path separator from the subdirectory
+		if subDir[0] == os.PathSeparator {
+			subDir = subDir[1:]
+		}
+		rs.subDir = subDir
+	}
+
+	// The archive and checksum parameters are handled here, the filename
+	// parameter is only handled in ClientModeAny so it is left in place.
+	gs := *s
+	gs.Getter, gs.Subdir = "", ""
+	gs.Archive, gs.Checksum, gs.ArchiveStrip, gs.ArchiveMember = "", "", "", ""
+	gs.Include, gs.Exclude = nil, nil
+	u, err := urlhelper.Parse(gs.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine if we have a forced protocol, i.e. "git::http://..."
+	force := s.Getter
+	if force == "" {
+		force = u.Scheme
+	}
+
+	g, ok := c.lookupGetter(force)
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnsupportedScheme, force)
+	}
+	rs.getterKey = force
+	rs.getter = g
+
+	// Determine if we have an archive type
+	archiveV := s.Archive
+	if archiveV != "" {
+		// If we can parse the value as a bool and it is false, then
+		// set the archive to "-" which should never map to a decompressor
+		if b, err := strconv.ParseBool(archiveV); err == nil && !b {
+			archiveV = "-"
+		}
+	}
+	if archiveV == "" {
+		// We don't appear to... but is it part of the filename?
+		rs.archiveByName = true
+		archiveV = c.archiveOf(u.Path)
+	}
+	if d := c.Decompressors[archiveV]; d != nil {
+		rs.archive = archiveV
+		rs.decompressor = d
+	} else if archiveV == archiveAuto {
+		// The decompressor is picked from the content of the download.
+		rs.archive = archiveV
+		rs.sniff = true
+	}
+
+	rs.strip, err = parseStripParam(s.ArchiveStrip)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ArchiveMember != "" {
+		rs.member = path.Clean("/" + filepath.ToSlash(s.ArchiveMember))[1:]
+		switch {
+		case rs.member == "" || containsDotDot(s.ArchiveMember):
+			return nil, fmt.Errorf("invalid archive_member %q", s.ArchiveMember)
+		case !rs.sniff && (rs.decompressor == nil || !extractsMembers(rs.decompressor)):
+			return nil, fmt.Errorf("archive_member requires a tar or zip archive")
+		case rs.subDir != "":
+			return nil, fmt.Errorf("archive_member cannot be combined with a subdirectory")
+		}
+	}
+
+	// Determine checksum if we have one
+	rs.checksumFile, rs.checksum, err = parseChecksumParam(s.Checksum, u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum: %w", err)
+	}
+
+	rs.filter, err = c.Filter.with(s.Include, s.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	rs.u = u
+	return rs, nil
+}
+
+// archiveOf returns the key of the decompressor whose extension name ends
+// with, preferring the longest, or "" if there is none.
+func (c *Client) archiveOf(name string) string {
+	var archive string
+	for k := range c.Decompressors {
+		if strings.HasSuffix(name, "."+k) && len(k) > len(archive) {
+			archive = k
+		}
+	}
+	return archive
+}
+
+// parseStripParam parses the "archive_strip" parameter, which is a number
+// of directories or "auto".
+func parseStripParam(v string) (int, error) {
+	switch v {
+	case "":
+		return 0, nil
+	case "auto":
+		return StripAuto, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid archive_strip %q: must be a number of directories or \"auto\"", v)
+	}
+	return n, nil
+}
@@ -0,0 +1,148 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClient_Resolve(t *testing.T) {
+	cases := []struct {
+		Name string
+		Src  string
+		Mode ClientMode
+		Plan *Plan
+		Err  string
+	}{
+		{
+			"detected with subdir",
+			"github.com/hashicorp/foo//modules/bar?ref=v1.0.0",
+			ClientModeDir,
+			&Plan{
+				URL:    "https://github.com/hashicorp/foo.git?ref=v1.0.0",
+				Getter: "git",
+				Subdir: "modules/bar",
+			},
+			"",
+		},
+		{
+			"archive and checksum",
+			"https://example.com/foo.tar.gz?checksum=sha256:a3ac5c5d2d0ff6a8e94a6b44a1c4cdf5a9cc7e1d2a12bb2d02fc9d9e4b37e6a0",
+			ClientModeDir,
+			&Plan{
+				URL:           "https://example.com/foo.tar.gz",
+				Getter:        "https",
+				Decompressor:  "tar.gz",
+				ChecksumType:  "sha256",
+				ChecksumValue: "a3ac5c5d2d0ff6a8e94a6b44a1c4cdf5a9cc7e1d2a12bb2d02fc9d9e4b37e6a0",
+			},
+			"",
+		},
+		{
+			"archive detected from the content",
+			"https://example.com/download?archive=auto&archive_member=foo",
+			ClientModeFile,
+			&Plan{
+				URL:           "https://example.com/download",
+				Getter:        "https",
+				Decompressor:  "auto",
+				ArchiveMember: "foo",
+			},
+			"",
+		},
+		{
+			"checksum file is not fetched",
+			"https://example.com/foo.zip?archive=false&checksum=file:https://example.com/SHA256SUMS",
+			ClientModeFile,
+			&Plan{
+				URL:           "https://example.com/foo.zip",
+				Getter:        "https",
+				ChecksumType:  "file",
+				ChecksumValue: "https://example.com/SHA256SUMS",
+			},
+			"",
+		},
+		{
+			"forced getter with filename and credentials",
+			"s3::https://s3.amazonaws.com/bucket/foo?filename=bar&aws_access_key_secret=secret",
+			ClientModeAny,
+			&Plan{
+				URL:      "https://s3.amazonaws.com/bucket/foo?aws_access_key_secret=redacted&filename=bar",
+				Getter:   "s3",
+				Filename: "bar",
+			},
+			"",
+		},
+		{
+			"query kept as written",
+			"https://example.com/foo.zip?z=1&archive=tar.gz&k&sig=a%2Bb",
+			ClientModeDir,
+			&Plan{
+				URL:          "https://example.com/foo.zip?z=1&k&sig=a%2Bb",
+				Getter:       "https",
+				Decompressor: "tar.gz",
+			},
+			"",
+		},
+		{
+			"malformed query",
+			"https://example.com/foo?sig=a;b&x=1",
+			ClientModeFile,
+			nil,
+			"invalid source query",
+		},
+		{
+			"unsupported scheme",
+			"nope://example.com/foo",
+			ClientModeDir,
+			nil,
+			"download not supported for scheme 'nope'",
+		},
+		{
+			"subdir traversal",
+			"https://example.com/foo//../bar",
+			ClientModeDir,
+			nil,
+			"path traversal",
+		},
+		{
+			"invalid checksum",
+			"https://example.com/foo?checksum=nope:abcd",
+			ClientModeFile,
+			nil,
+			"unsupported checksum type",
+		},
+		{
+			"filename traversal",
+			"https://example.com/foo?filename=../bar",
+			ClientModeAny,
+			nil,
+			"filename query parameter contain path traversal",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client := &Client{
+				Src:  tc.Src,
+				Mode: tc.Mode,
+			}
+			plan, err := client.Resolve()
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("expected error containing %q, got %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(plan, tc.Plan) {
+				t.Fatalf("bad plan\nexpected: %#v\ngot:      %#v", tc.Plan, plan)
+			}
+		})
+	}
+}
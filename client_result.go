@@ -0,0 +1,106 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GetResult describes what a call to Client.GetWithResult fetched. It is
+// intended for audit logs and lockfiles.
+type GetResult struct {
+	// Detector is the detector that rewrote the source into a URL. It is
+	// nil if the source was already a valid URL.
+	Detector Detector
+
+	// Getter is the key in Client.Getters of the getter that downloaded
+	// the source, such as "git" or "https".
+	Getter string
+
+	// URL is the URL that was passed to the getter, with any credentials
+	// redacted by RedactURL.
+	URL string
+
+	// Mode is the mode of what was written to the destination: either
+	// ClientModeFile or ClientModeDir. When the client runs in
+	// ClientModeAny this is the mode that was picked.
+	Mode ClientMode
+
+	// ChecksumType is the type of the checksum requested with the
+	// "checksum" parameter, such as "sha256". It is empty if no checksum
+	// was requested.
+	//
+	// ChecksumVerified is true if the downloaded file was checked against
+	// the checksum. ChecksumSkipped is true if the download was skipped
+	// because the destination already matched the checksum.
+	ChecksumType     string
+	ChecksumVerified bool
+	ChecksumSkipped  bool
+
+	// Decompressor is the key in Client.Decompressors of the decompressor
+	// that unpacked the download, such as "tar.gz". It is empty if the
+	// download was not an archive.
+	Decompressor string
+
+	// ContentType and Filename are the media type and the file name that
+	// the server reported for a file download, with the Content-Type and
+	// Content-Disposition headers of HTTP. They are empty if the getter
+	// did not report them.
+	ContentType string
+	Filename    string
+
+	// Files and Bytes are the number of regular files and their total
+	// size found at the destination once the download completed.
+	Files int
+	Bytes int64
+
+	// Version is the immutable version the getter resolved the source to,
+	// if it supports one: the commit SHA for git, the changeset for
+	// Mercurial, the VersionId for S3, the object generation for GCS and
+	// the ETag for HTTP. It is empty if the getter did not report one.
+	Version string
+
+	// SHA256 is the hex encoded sha256 of the downloaded file, before it
+	// was decompressed. It is only computed for file downloads by clients
+	// with a Lockfile.
+	SHA256 string
+}
+
+// countFiles returns the number of regular files and their total size
+// found at path on fsys. If path is a symlink on the OS filesystem it is
+// followed.
+func countFiles(fsys WritableFS, path string) (int, int64, error) {
+	if isOSFS(fsys) {
+		resolved, err := filepath.EvalSymlinks(path)
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		path = resolved
+	}
+
+	var (
+		files int
+		bytes int64
+	)
+	err := walkFS(destFS(fsys), path, func(_ string, fi os.FileInfo) error {
+		if fi.Mode().IsRegular() {
+			files++
+			bytes += fi.Size()
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return files, bytes, nil
+}
@@ -0,0 +1,169 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+)
+
+// streamDecompressor returns the decompressor of rs as a StreamDecompressor
+// if the archive can be unpacked as it is downloaded. The getter must be
+// an Opener, and the root directory to strip must not be detected.
+func (c *Client) streamDecompressor(rs *resolvedSource) (StreamDecompressor, bool) {
+	sd, ok := rs.decompressor.(StreamDecompressor)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := rs.getter.(Opener); !ok {
+		return nil, false
+	}
+	if rs.strip == StripAuto {
+		return nil, false
+	}
+	return sd, true
+}
+
+// getStream opens the archive of rs and unpacks it with sd as it is read,
+// for req. The checksum and the SHA256 of the lockfile are hashed from the
+// same stream.
+//
+// Every attempt unpacks into a staging directory of its own next to
+// req.Dst, which is only moved into place once the whole archive was read
+// and matched the checksum. A failed attempt leaves req.Dst as it was.
+func (c *Client) getStream(rs *resolvedSource, u *url.URL, sd StreamDecompressor, checksum *FileChecksum, pin *LockedSource, result *GetResult, req *DecompressRequest) error {
+	o := rs.getter.(Opener)
+	fsys := destFS(req.FS)
+	parent := filepath.Dir(req.Dst)
+	if err := fsys.MkdirAll(parent, c.mode(0755)); err != nil {
+		return err
+	}
+
+	var sha hash.Hash
+	err := c.observeGetter(rs.getterKey, result.URL, ClientModeFile, func() error {
+		return c.retry(c.Ctx, func() error {
+			body, md, err := o.OpenContext(c.requestContext(), &GetterRequest{Client: c, URL: u})
+			if err != nil {
+				return err
+			}
+			defer func() { _ = body.Close() }()
+			if err := checkPin(pin, result); err != nil {
+				return err
+			}
+
+			if md.Name == "" {
+				md.Name = filepath.Base(u.Path)
+			}
+			if c.RateLimiter != nil {
+				body = &rateLimitedReader{ReadCloser: body, ctx: c.Ctx, limiter: c.RateLimiter}
+			}
+			if c.ProgressListener != nil {
+				body = c.ProgressListener.TrackProgress(md.Name, 0, max(md.Size, 0), body)
+			}
+
+			// Every attempt hashes the archive from the start.
+			var hashes []io.Writer
+			if checksum != nil {
+				checksum.Hash.Reset()
+				hashes = append(hashes, checksum.Hash)
+			}
+			if c.Lockfile != nil {
+				sha = sha256.New()
+				hashes = append(hashes, sha)
+			}
+			var r io.Reader = body
+			if len(hashes) > 0 {
+				r = io.TeeReader(body, io.MultiWriter(hashes...))
+			}
+
+			staging, err := mkdirTempFS(fsys, parent, "."+filepath.Base(req.Dst)+".getter-")
+			if err != nil {
+				return err
+			}
+			defer func() { _ = fsys.RemoveAll(staging) }()
+			sreq := *req
+			sreq.Dst = filepath.Join(staging, filepath.Base(req.Dst))
+			sreq.FS = withNewQuota(fsys)
+
+			// Unpacking is rate limited by the body, not by its writes.
+			if err := sd.DecompressStream(withRateLimiter(c.Ctx, nil), &sreq, r); err != nil {
+				return err
+			}
+
+			// Decompressors may stop at the end of the archive, before the
+			// end of the stream, like tar does at its end marker.
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return err
+			}
+
+			if checksum != nil {
+				err := checksum.verify(result.URL)
+				c.observer().OnChecksum(checksum.Type, err == nil)
+				if err != nil {
+					return err
+				}
+			}
+			return mergePath(fsys, sreq.Dst, req.Dst)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if checksum != nil {
+		result.ChecksumVerified = true
+	}
+	if sha != nil {
+		result.SHA256 = hex.EncodeToString(sha.Sum(nil))
+	}
+	return nil
+}
+
+// mergePath moves src to dst on fsys like replacePath, except that the
+// content of a src directory is merged into an existing dst directory, as
+// if it had been unpacked there. Merged subdirectories take the mode of
+// their counterpart in src.
+func mergePath(fsys WritableFS, src, dst string) error {
+	var merge func(src, dst string, top bool) error
+	merge = func(src, dst string, top bool) error {
+		srcFi, err := fsys.Lstat(src)
+		if err != nil {
+			return err
+		}
+		dstFi, err := fsys.Lstat(dst)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err != nil || !srcFi.IsDir() || !dstFi.IsDir() {
+			return replacePath(fsys, src, dst)
+		}
+
+		// The archive may have made the directory read-only.
+		if err := fsys.Chmod(src, srcFi.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		entries, err := fsys.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := merge(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), false); err != nil {
+				return err
+			}
+		}
+		if !top {
+			if err := fsys.Chmod(dst, srcFi.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		return fsys.Remove(src)
+	}
+	return merge(src, dst, true)
+}
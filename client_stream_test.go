@@ -0,0 +1,143 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+// testStreamGetter is a FileGetter that can only open files as a stream.
+type testStreamGetter struct {
+	*FileGetter
+}
+
+func (g *testStreamGetter) Clone() Getter {
+	return &testStreamGetter{FileGetter: g.FileGetter.Clone().(*FileGetter)}
+}
+
+func (g *testStreamGetter) GetFileContext(context.Context, *GetterRequest) error {
+	return errors.New("the archive was downloaded to a file")
+}
+
+// testFailingStreamGetter is a testStreamGetter whose streams fail once
+// all of their content was read.
+type testFailingStreamGetter struct {
+	*testStreamGetter
+}
+
+func (g *testFailingStreamGetter) Clone() Getter {
+	return &testFailingStreamGetter{g.testStreamGetter.Clone().(*testStreamGetter)}
+}
+
+func (g *testFailingStreamGetter) OpenContext(ctx context.Context, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	body, md, err := g.testStreamGetter.OpenContext(ctx, gr)
+	if err != nil {
+		return nil, md, err
+	}
+	r := io.MultiReader(body, iotest.ErrReader(errors.New("connection reset")))
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, body}, md, nil
+}
+
+func TestGet_archiveStream(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(fixtureDir, "decompress-tgz/single.tar.gz"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	sum := sha256.Sum256(data)
+	src := "stream::" + testModule("decompress-tgz/single.tar.gz")
+
+	newClient := func(src string, opts ...ClientOption) *Client {
+		return &Client{
+			Src:     src,
+			Dst:     filepath.Join(t.TempDir(), "target"),
+			Mode:    ClientModeDir,
+			Getters: map[string]Getter{"stream": &testStreamGetter{FileGetter: new(FileGetter)}},
+			Options: opts,
+		}
+	}
+
+	lockfile := NewLockfile()
+	client := newClient(src, WithLockfile(lockfile))
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(client.Dst, "file"), "foo\n")
+	if result.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("bad SHA256: %s", result.SHA256)
+	}
+
+	// Checksums are verified before anything is moved to the destination.
+	checksum := "?checksum=sha256:" + hex.EncodeToString(sum[:])
+	client = newClient(src + checksum)
+	result, err = client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !result.ChecksumVerified {
+		t.Fatal("expected the checksum to be verified")
+	}
+	assertContents(t, filepath.Join(client.Dst, "file"), "foo\n")
+
+	client = newClient(src + "?checksum=sha256:" + hex.EncodeToString(make([]byte, sha256.Size)))
+	var cerr *ChecksumError
+	if err := client.Get(); !errors.As(err, &cerr) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if _, err := os.Stat(client.Dst); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written: %v", err)
+	}
+}
+
+func TestGet_archiveStreamFailure(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "previous"), []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The archive is unpacked in full before the transfer fails, but none
+	// of it reaches the destination.
+	getter := &testFailingStreamGetter{&testStreamGetter{FileGetter: new(FileGetter)}}
+	client := &Client{
+		Src:     "stream::" + testModule("decompress-tgz/single.tar.gz"),
+		Dst:     dst,
+		Mode:    ClientModeDir,
+		Getters: map[string]Getter{"stream": getter},
+	}
+	if err := client.Get(); err == nil {
+		t.Fatal("expected the transfer to fail")
+	}
+	if files := testListFiles(t, dst); len(files) != 1 || files[0] != "previous" {
+		t.Fatalf("expected the destination to be left as it was, got %v", files)
+	}
+	entries, err := os.ReadDir(filepath.Dir(dst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the staging directory to be removed, got %d entries", len(entries))
+	}
+
+	// A successful transfer is merged into the destination.
+	client.Getters = map[string]Getter{"stream": getter.testStreamGetter}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "file"), "foo\n")
+	assertContents(t, filepath.Join(dst, "previous"), "previous")
+}
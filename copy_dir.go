@@ -16,11 +16,15 @@ func mode(mode, umask os.FileMode) os.FileMode {
 	return mode & ^umask
 }
 
-// copyDir copies the src directory contents into dst. Both directories
-// should already exist.
+// copyDir copies the src directory contents into dst, which is written
+// through fsys. Both directories should already exist.
 //
 // If ignoreDot is set to true, then dot-prefixed files/folders are ignored.
-func copyDir(ctx context.Context, dst string, src string, ignoreDot bool, disableSymlinks bool, umask os.FileMode) error {
+// Only the files selected by filter are copied, along with the directories
+// they are in.
+func copyDir(ctx context.Context, fsys WritableFS, dst string, src string, ignoreDot bool, disableSymlinks bool, umask os.FileMode, filter *Filter) error {
+	fsys = destFS(fsys)
+
 	// We can safely evaluate the symlinks here, even if disabled, because they
 	// will be checked before actual use in walkFn and copyFile
 	resolved, err := resolveSymlinks(src)
@@ -67,6 +71,7 @@ func copyDir(ctx context.Context, dst string, src string, ignoreDot bool, disabl
 		// The "path" has the src prefixed to it. We need to join our
 		// destination with the path without the src on it.
 		dstPath := filepath.Join(dst, path[len(resolved):])
+		rel := filepath.ToSlash(strings.TrimPrefix(path[len(resolved):], string(filepath.Separator)))
 
 		// If we have a directory, make that subdirectory, then continue
 		// the walk.
@@ -75,15 +80,31 @@ func copyDir(ctx context.Context, dst string, src string, ignoreDot bool, disabl
 				// dst is in src; don't walk it.
 				return nil
 			}
-			if err := os.MkdirAll(dstPath, mode(0755, umask)); err != nil {
+			if !filter.matchDir(rel) {
+				return filepath.SkipDir
+			}
+			if filter != nil {
+				// Only the directories of selected files are created.
+				return nil
+			}
+			if err := fsys.MkdirAll(dstPath, mode(0755, umask)); err != nil {
 				return err
 			}
 
 			return nil
 		}
 
+		if !filter.Match(rel) {
+			return nil
+		}
+		if filter != nil {
+			if err := fsys.MkdirAll(filepath.Dir(dstPath), mode(0755, umask)); err != nil {
+				return err
+			}
+		}
+
 		// If we have a file, copy the contents.
-		_, err = copyFile(ctx, dstPath, path, disableSymlinks, info.Mode(), umask)
+		_, err = copyFile(ctx, fsys, dstPath, path, disableSymlinks, info.Mode(), umask)
 		return err
 	}
 
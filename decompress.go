@@ -4,7 +4,12 @@
 package getter
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -22,6 +27,181 @@ type Decompressor interface {
 	Decompress(dst, src string, dir bool, umask os.FileMode) error
 }
 
+// DecompressRequest is a single call to a DecompressorContext. Its fields
+// are the arguments of Decompressor.Decompress, and the filesystem to
+// write Dst to.
+type DecompressRequest struct {
+	Dst   string
+	Src   string
+	Dir   bool
+	Umask os.FileMode
+
+	// FS is the filesystem to write Dst to. If it is nil, Dst is on the
+	// OS filesystem. Src is always on the OS filesystem.
+	FS WritableFS
+
+	// Filter selects the files of the archive to unpack when Dir is true.
+	// If it is nil, every file is unpacked.
+	Filter *Filter
+
+	// StripComponents is the number of leading path elements removed from
+	// the names of the entries of the archive when Dir is true or Member
+	// is set, like the --strip-components flag of tar. Entries with no
+	// elements left are skipped. If it is StripAuto, a single directory at
+	// the root of the archive is unwrapped.
+	StripComponents int
+
+	// Member is the slash separated path of the entry of the archive to
+	// unpack, if not the whole archive. When Dir is true, the entries in
+	// the Member directory are unpacked relative to it into Dst, and a
+	// Member that is a file is unpacked into Dst under its name. When Dir
+	// is false, the Member file is unpacked to Dst. Only the tar and zip
+	// decompressors support it.
+	Member string
+
+	// DisableSymlinks makes the symlinks of the archive an error, rather
+	// than extracting the ones that stay inside Dst.
+	DisableSymlinks bool
+
+	// Observer, if set, is told about the archive once it is unpacked,
+	// with Kind as the kind of decompressor. The built-in decompressors
+	// call its OnDecompress with the files and bytes they wrote.
+	Observer Observer
+	Kind     string
+}
+
+// observe reports the files and bytes unpacked for req to its Observer.
+func (req *DecompressRequest) observe(files int, bytes int64) {
+	if req.Observer != nil {
+		req.Observer.OnDecompress(req.Kind, files, bytes)
+	}
+}
+
+// StripAuto is the DecompressRequest.StripComponents that unwraps the root
+// directory of an archive if all its entries are in it.
+const StripAuto = -1
+
+// DecompressorContext is implemented by decompressors that can be
+// cancelled. The client prefers it over Decompressor.
+type DecompressorContext interface {
+	// DecompressContext is like Decompress, but must stop as soon as ctx
+	// is done.
+	DecompressContext(ctx context.Context, req *DecompressRequest) error
+}
+
+// decompress runs d, passing it ctx if it is a DecompressorContext.
+func decompress(ctx context.Context, d Decompressor, req *DecompressRequest) error {
+	if dc, ok := d.(DecompressorContext); ok {
+		return dc.DecompressContext(ctx, req)
+	}
+
+	// A Decompressor unpacks whole archives to the OS filesystem. Other
+	// filesystems and filters are handled by unpacking to a temporary
+	// directory, but entries can't be stripped or picked.
+	if req.StripComponents != 0 || req.Member != "" {
+		return fmt.Errorf("the %s decompressor doesn't support archive_strip or archive_member", req.Kind)
+	}
+	var err error
+	if !isOSFS(req.FS) || req.Filter != nil {
+		err = decompressThroughOS(ctx, d, req)
+	} else {
+		err = d.Decompress(req.Dst, req.Src, req.Dir, req.Umask)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Decompressors without a request can't report what they unpacked,
+	// so what is in the destination is counted instead.
+	if req.Observer != nil {
+		files, bytes, err := countFiles(destFS(req.FS), req.Dst)
+		if err != nil {
+			return err
+		}
+		req.observe(files, bytes)
+	}
+	return nil
+}
+
+// decompressThroughOS runs d to unpack req.Src into a temporary directory
+// and copies the files selected by req.Filter to req.Dst on req.FS.
+func decompressThroughOS(ctx context.Context, d Decompressor, req *DecompressRequest) error {
+	td, tdcloser, err := mkdirTemp("", "getter")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tdcloser.Close() }()
+
+	tmpDst := filepath.Join(td, "dst")
+	if err := d.Decompress(tmpDst, req.Src, req.Dir, req.Umask); err != nil {
+		return err
+	}
+
+	fsys := destFS(req.FS)
+	if !req.Dir {
+		fi, err := os.Stat(tmpDst)
+		if err != nil {
+			return err
+		}
+		if err := fsys.MkdirAll(filepath.Dir(req.Dst), mode(0755, req.Umask)); err != nil {
+			return err
+		}
+		_, err = copyFile(ctx, fsys, req.Dst, tmpDst, req.DisableSymlinks, fi.Mode().Perm(), req.Umask)
+		return err
+	}
+	if err := fsys.MkdirAll(req.Dst, mode(0755, req.Umask)); err != nil {
+		return err
+	}
+	return copyDir(ctx, fsys, req.Dst, tmpDst, false, req.DisableSymlinks, req.Umask, req.Filter)
+}
+
+// copyDecompressed copies the decompressed content of a single file
+// archive from r to req.Dst on fsys, and reports it to the Observer of
+// req.
+func copyDecompressed(ctx context.Context, fsys WritableFS, req *DecompressRequest, r io.Reader, fileSizeLimit int64) error {
+	if err := copyReader(ctx, fsys, req.Dst, r, 0622, req.Umask, fileSizeLimit); err != nil {
+		return err
+	}
+	if req.Observer != nil {
+		fi, err := fsys.Lstat(req.Dst)
+		if err != nil {
+			return err
+		}
+		req.observe(1, fi.Size())
+	}
+	return nil
+}
+
+// ReaderDecompressor is implemented by decompressors of single files that
+// can decompress a stream. Client.Open uses it to decompress on the fly.
+type ReaderDecompressor interface {
+	// DecompressReader returns a reader of the decompressed content of r.
+	// Closing it does not close r.
+	DecompressReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// StreamDecompressor is implemented by decompressors that can unpack an
+// archive as it is read. The client uses it for sources a getter can open
+// as a stream, so the archive isn't staged in a temporary file first.
+type StreamDecompressor interface {
+	// DecompressStream is like DecompressContext, but reads the archive
+	// from r. req.Src only names the archive in errors. StripAuto isn't
+	// supported, since it needs to read the archive twice.
+	DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error
+}
+
+// decompressedReader returns r as a ReadCloser that stops after limit
+// bytes, if limit is positive, and calls closeFn when closed.
+func decompressedReader(r io.Reader, limit int64, closeFn func() error) io.ReadCloser {
+	if limit > 0 {
+		r = io.LimitReader(r, limit)
+	}
+	if closeFn == nil {
+		closeFn = func() error { return nil }
+	}
+	return &limitedWrappedReaderCloser{underlying: r, closeFn: closeFn}
+}
+
 // LimitedDecompressors creates the set of Decompressors, but with each compressor configured
 // with the given filesLimit and/or fileSizeLimit where applicable.
 func LimitedDecompressors(filesLimit int, fileSizeLimit int64) map[string]Decompressor {
@@ -66,6 +246,123 @@ const (
 // maximum file size created by the decompressed payload.
 var Decompressors = LimitedDecompressors(noFilesLimit, noFileSizeLimit)
 
+// nameElems returns the elements of the slash separated name of an archive
+// entry, without empty and "." elements.
+func nameElems(name string) []string {
+	var elems []string
+	for _, e := range strings.Split(name, "/") {
+		if e != "" && e != "." {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// stripName returns the name of an archive entry without its first n
+// elements, or "" if it has none left.
+func stripName(name string, n int) string {
+	elems := nameElems(name)
+	if len(elems) <= n {
+		return ""
+	}
+	return strings.Join(elems[n:], "/")
+}
+
+// archiveRoot finds the single directory at the root of an archive from
+// the names of its entries.
+type archiveRoot struct {
+	root     string
+	isDir    bool
+	multiple bool
+}
+
+// add accounts for an entry of the archive.
+func (r *archiveRoot) add(name string, dir bool) {
+	elems := nameElems(name)
+	if len(elems) == 0 || r.multiple {
+		return
+	}
+
+	switch {
+	case r.root == "":
+		r.root = elems[0]
+	case r.root != elems[0]:
+		r.multiple = true
+		return
+	}
+	r.isDir = r.isDir || dir || len(elems) > 1
+}
+
+// strip returns the number of elements to strip from the names of the
+// entries to unwrap the root directory, 1 or 0.
+func (r *archiveRoot) strip() int {
+	if r.root != "" && r.root != ".." && r.isDir && !r.multiple {
+		return 1
+	}
+	return 0
+}
+
+// extractsMembers reports whether d supports DecompressRequest.Member.
+func extractsMembers(d Decompressor) bool {
+	switch d.(type) {
+	case *TarDecompressor, *TarBzip2Decompressor, *TarGzipDecompressor,
+		*TarXzDecompressor, *TarZstdDecompressor, *ZipDecompressor:
+		return true
+	}
+	return false
+}
+
+// entrySelector selects the entries of an archive to unpack for a
+// DecompressRequest, after its StripComponents are resolved.
+type entrySelector struct {
+	strip  int
+	member string
+	filter *Filter
+}
+
+// newEntrySelector returns the entrySelector of req, which strips strip
+// elements from the names of entries.
+func newEntrySelector(req *DecompressRequest, strip int) entrySelector {
+	return entrySelector{strip: max(strip, 0), member: stripName(req.Member, 0), filter: req.Filter}
+}
+
+// dirName returns the name of the entry name to unpack it to relative to
+// the destination directory, or "" if it isn't unpacked. inMember reports
+// whether the entry is the member or in it, if there is a member.
+func (s entrySelector) dirName(name string, isDir bool) (rel string, inMember bool) {
+	if s.strip <= 0 && s.member == "" {
+		return name, true
+	}
+
+	name = stripName(name, s.strip)
+	switch {
+	case s.member == "":
+		return name, true
+	case name == s.member && isDir:
+		return "", true
+	case name == s.member:
+		return path.Base(name), true
+	case strings.HasPrefix(name, s.member+"/"):
+		return name[len(s.member)+1:], true
+	}
+	return "", false
+}
+
+// isFile reports whether the entry name is the file to unpack when
+// unpacking a single file. Without a member, every entry is.
+func (s entrySelector) isFile(name string, isDir bool) bool {
+	return s.member == "" || (!isDir && stripName(name, s.strip) == s.member)
+}
+
+// notFound returns the error for a member that isn't in the archive src.
+func (s entrySelector) notFound(src string, dir bool) error {
+	err := fmt.Errorf("archive member %q not found in %s", s.member, src)
+	if dir {
+		return withSentinel(err, ErrSubdirNotFound)
+	}
+	return withSentinel(err, ErrNotFound)
+}
+
 // containsDotDot checks if the filepath value v contains a ".." entry.
 // This will check filepath components by splitting along / or \. This
 // function is copied directly from the Go net/http implementation.
@@ -5,7 +5,9 @@ package getter
 
 import (
 	"compress/bzip2"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -20,26 +22,44 @@ type Bzip2Decompressor struct {
 }
 
 func (d *Bzip2Decompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *Bzip2Decompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	// File first
+	f, err := os.Open(req.Src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return d.DecompressStream(ctx, req, f)
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *Bzip2Decompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	dst, dir, umask := req.Dst, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	// Directory isn't supported at all
 	if dir {
 		return fmt.Errorf("bzip2-compressed files can only unarchive to a single file")
 	}
 
 	// If we're going into a directory we should make that first
-	if err := os.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
 		return err
 	}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
 	// Bzip2 compression is second
-	bzipR := bzip2.NewReader(f)
+	bzipR := bzip2.NewReader(r)
 
 	// Copy it out
-	return copyReader(dst, bzipR, 0622, umask, d.FileSizeLimit)
+	return copyDecompressed(ctx, fsys, req, bzipR, d.FileSizeLimit)
+}
+
+// DecompressReader implements ReaderDecompressor.
+func (d *Bzip2Decompressor) DecompressReader(r io.Reader) (io.ReadCloser, error) {
+	return decompressedReader(bzip2.NewReader(r), d.FileSizeLimit, nil), nil
 }
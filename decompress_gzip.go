@@ -5,7 +5,9 @@ package getter
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -20,30 +22,52 @@ type GzipDecompressor struct {
 }
 
 func (d *GzipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *GzipDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	// File first
+	f, err := os.Open(req.Src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return d.DecompressStream(ctx, req, f)
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *GzipDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	dst, dir, umask := req.Dst, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	// Directory isn't supported at all
 	if dir {
 		return fmt.Errorf("gzip-compressed files can only unarchive to a single file")
 	}
 
 	// If we're going into a directory we should make that first
-	if err := os.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
 		return err
 	}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
 	// gzip compression is second
-	gzipR, err := gzip.NewReader(f)
+	gzipR, err := gzip.NewReader(r)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = gzipR.Close() }()
 
 	// Copy it out
-	return copyReader(dst, gzipR, 0622, umask, d.FileSizeLimit)
+	return copyDecompressed(ctx, fsys, req, gzipR, d.FileSizeLimit)
+}
+
+// DecompressReader implements ReaderDecompressor.
+func (d *GzipDecompressor) DecompressReader(r io.Reader) (io.ReadCloser, error) {
+	gzipR, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return decompressedReader(gzipR, d.FileSizeLimit, gzipR.Close), nil
 }
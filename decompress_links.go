@@ -0,0 +1,191 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LinkFS is implemented by a WritableFS that can create hard links. The
+// hard links of archives unpacked to other filesystems are copies of the
+// file they link to.
+type LinkFS interface {
+	Link(oldname, newname string) error
+}
+
+// archiveLinks extracts the links of an archive into dst, and keeps the
+// other entries from being written through symlinks.
+type archiveLinks struct {
+	fsys            WritableFS
+	dst             string
+	disableSymlinks bool
+}
+
+func newArchiveLinks(fsys WritableFS, dst string, disableSymlinks bool) *archiveLinks {
+	return &archiveLinks{fsys: fsys, dst: dst, disableSymlinks: disableSymlinks}
+}
+
+// checkDirs returns an error if one of the directories the entry name is
+// in is a symlink. The directories are checked on disk, so symlinks that
+// were in dst before the archive was extracted aren't followed either.
+func (l *archiveLinks) checkDirs(name string) error {
+	elems := strings.Split(filepath.ToSlash(filepath.Clean(name)), "/")
+	p := l.dst
+	for i := 1; i < len(elems); i++ {
+		p = filepath.Join(p, elems[i-1])
+		fi, err := l.fsys.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Nothing below it exists yet.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("entry %s is inside symlink %s", name, strings.Join(elems[:i], "/"))
+		}
+	}
+	return nil
+}
+
+// prepare makes the entry name ready to be written: it checks the
+// directories it is in, and removes a symlink at its path so that it isn't
+// written through.
+func (l *archiveLinks) prepare(name string) error {
+	if err := l.checkDirs(name); err != nil {
+		return err
+	}
+
+	p := filepath.Join(l.dst, name)
+	fi, err := l.fsys.Lstat(p)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	return l.fsys.Remove(p)
+}
+
+// symlink creates the symlink entry name pointing to target, which must
+// stay inside dst.
+func (l *archiveLinks) symlink(name, target string, umask os.FileMode) error {
+	if l.disableSymlinks {
+		return fmt.Errorf("archive contains symlink %s: %w", name, ErrSymlinkCopy)
+	}
+
+	if !symlinkInside(filepath.ToSlash(filepath.Clean(name)), target) {
+		return fmt.Errorf("symlink %s points outside of the destination: %s", name, target)
+	}
+
+	if err := l.prepare(name); err != nil {
+		return err
+	}
+	p := filepath.Join(l.dst, name)
+	if err := l.fsys.MkdirAll(filepath.Dir(p), mode(0755, umask)); err != nil {
+		return err
+	}
+	if err := l.removeFile(p); err != nil {
+		return err
+	}
+	return l.fsys.Symlink(target, p)
+}
+
+// symlinkInside reports whether target, of the symlink at the slash
+// separated path name, stays inside the directory name is relative to.
+// Since the directories of the target may themselves be symlinks, ".."
+// elements are only allowed at its start, where they leave the real
+// directories name is in.
+func symlinkInside(name, target string) bool {
+	slashTarget := filepath.ToSlash(target)
+	if target == "" || path.IsAbs(slashTarget) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return false
+	}
+
+	depth := len(strings.Split(path.Dir(name), "/"))
+	if path.Dir(name) == "." {
+		depth = 0
+	}
+	descending := false
+	for _, e := range strings.Split(slashTarget, "/") {
+		switch {
+		case e == "" || e == ".":
+		case e == ".." && descending:
+			return false
+		case e == "..":
+			if depth--; depth < 0 {
+				return false
+			}
+		default:
+			descending = true
+		}
+	}
+	return true
+}
+
+// hardLink creates the hard link entry name to the entry target, which
+// must have been extracted before. It returns the size of target.
+func (l *archiveLinks) hardLink(ctx context.Context, name, target string, umask os.FileMode) (int64, error) {
+	if target == "" || containsDotDot(target) || path.IsAbs(filepath.ToSlash(target)) {
+		return 0, fmt.Errorf("hard link %s points outside of the destination: %s", name, target)
+	}
+	// With the directories of target checked, Lstat doesn't resolve any
+	// symlink on the way to it.
+	if err := l.checkDirs(target); err != nil {
+		return 0, err
+	}
+
+	oldname := filepath.Join(l.dst, target)
+	fi, err := l.fsys.Lstat(oldname)
+	if err != nil {
+		return 0, fmt.Errorf("hard link %s points to %s, which isn't extracted: %w", name, target, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return 0, fmt.Errorf("hard link %s doesn't point to a file: %s", name, target)
+	}
+
+	if err := l.prepare(name); err != nil {
+		return 0, err
+	}
+	newname := filepath.Join(l.dst, name)
+	if err := l.fsys.MkdirAll(filepath.Dir(newname), mode(0755, umask)); err != nil {
+		return 0, err
+	}
+	if err := l.removeFile(newname); err != nil {
+		return 0, err
+	}
+
+	if lfs, ok := l.fsys.(LinkFS); ok {
+		err := lfs.Link(oldname, newname)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return fi.Size(), err
+		}
+	}
+
+	f, err := l.fsys.OpenFile(oldname, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	return fi.Size(), copyReader(ctx, l.fsys, newname, f, fi.Mode(), umask, 0)
+}
+
+// removeFile removes what isn't a directory at p, for a link to replace.
+func (l *archiveLinks) removeFile(p string) error {
+	fi, err := l.fsys.Lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("link %s would replace a directory", p)
+	}
+	return l.fsys.Remove(p)
+}
@@ -0,0 +1,184 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveAuto is the value of the "archive" parameter that detects the
+// decompressor from the content of the download.
+const archiveAuto = "auto"
+
+// sniffHeaderSize is the number of bytes read to detect an archive, enough
+// for the magic of a tar header.
+const sniffHeaderSize = 512
+
+// compressionMagics maps the magic bytes of compressed files to the key of
+// their decompressor and to the one of the tar decompressor of the same
+// compression.
+var compressionMagics = []struct {
+	magic      []byte
+	key        string
+	tarKey     string
+	uncompress ReaderDecompressor
+}{
+	{[]byte{0x1f, 0x8b}, "gz", "tar.gz", new(GzipDecompressor)},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, "zst", "tar.zst", new(ZstdDecompressor)},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz", "tar.xz", new(XzDecompressor)},
+	{[]byte("BZh"), "bz2", "tar.bz2", new(Bzip2Decompressor)},
+}
+
+// archiveContentTypes maps media types to the key of their decompressor,
+// for archives without magic bytes.
+var archiveContentTypes = map[string]string{
+	"application/gzip":             "gz",
+	"application/x-gzip":           "gz",
+	"application/zstd":             "zst",
+	"application/x-xz":             "xz",
+	"application/x-bzip2":          "bz2",
+	"application/zip":              "zip",
+	"application/x-zip-compressed": "zip",
+	"application/x-tar":            "tar",
+	"application/x-gtar":           "tar.gz",
+	"application/x-compressed-tar": "tar.gz",
+}
+
+// sniffArchive returns the key in decompressors of the decompressor of the
+// file at path, which is detected from its magic bytes. Compressed files
+// are checked for a tar archive inside. The media type and file name the
+// server reported, if any, are used for archives without magic bytes. It
+// returns "" if the file isn't a known archive.
+func sniffArchive(path, contentType, filename string, decompressors map[string]Decompressor) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	header, err := readHeader(f)
+	if err != nil {
+		return "", err
+	}
+
+	key := ""
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		key = "zip"
+	case isTarHeader(header):
+		key = "tar"
+	default:
+		for _, m := range compressionMagics {
+			if !bytes.HasPrefix(header, m.magic) {
+				continue
+			}
+			key = m.key
+
+			// Check for a tar archive inside the compression layer.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return "", err
+			}
+			r, err := m.uncompress.DecompressReader(f)
+			if err != nil {
+				break
+			}
+			inner, err := readHeader(r)
+			_ = r.Close()
+			if err == nil && isTarHeader(inner) {
+				key = m.tarKey
+			}
+			break
+		}
+	}
+
+	if key == "" {
+		key = archiveHint(contentType, filename, decompressors)
+	}
+	if decompressors[key] == nil {
+		return "", nil
+	}
+	return key, nil
+}
+
+// readHeader reads the first sniffHeaderSize bytes of r, or all of it if
+// it is shorter.
+func readHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, sniffHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return header[:n], err
+}
+
+// isTarHeader reports whether header starts with a POSIX or GNU tar header.
+func isTarHeader(header []byte) bool {
+	return len(header) >= 263 && bytes.Equal(header[257:262], []byte("ustar"))
+}
+
+// archiveHint returns the key in decompressors of the decompressor that
+// filename or contentType suggest, or "".
+func archiveHint(contentType, filename string, decompressors map[string]Decompressor) string {
+	key, matchingLen := "", 0
+	for k := range decompressors {
+		if strings.HasSuffix(filename, "."+k) && len(k) > matchingLen {
+			key = k
+			matchingLen = len(k)
+		}
+	}
+	if key != "" {
+		return key
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return archiveContentTypes[mediaType]
+	}
+	return ""
+}
+
+// copyUnarchived copies the file at src, downloaded with "archive=auto"
+// but not an archive, to dst on fsys. If dir is set, dst is a directory
+// which is only allowed in ClientModeAny, and the file is named after the
+// "filename" parameter, the name the server reported or the URL. It
+// returns the path of the copy.
+func (c *Client) copyUnarchived(fsys WritableFS, dst, src string, dir bool, subDir string, u *url.URL, result *GetResult) (string, error) {
+	if dir {
+		if c.Mode != ClientModeAny || subDir != "" {
+			return "", fmt.Errorf("%s is not an archive", RedactURL(u))
+		}
+
+		filename := filepath.Base(u.Path)
+		if v := u.Query().Get("filename"); v != "" {
+			filename = v
+		} else if result.Filename != "" {
+			filename = result.Filename
+		}
+		if containsDotDot(filename) {
+			return "", fmt.Errorf("filename query parameter contain path traversal")
+		}
+		dst = filepath.Join(dst, filename)
+	}
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if err := fsys.MkdirAll(filepath.Dir(dst), c.mode(0755)); err != nil {
+		return "", err
+	}
+	// The copy is local, so it isn't rate limited.
+	ctx := withRateLimiter(c.Ctx, nil)
+	if _, err := copyFile(ctx, fsys, dst, src, false, fi.Mode(), c.umask()); err != nil {
+		return "", err
+	}
+	result.Mode = ClientModeFile
+	return dst, nil
+}
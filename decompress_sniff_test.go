@@ -0,0 +1,131 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSniffArchive(t *testing.T) {
+	cases := []struct {
+		Input       string
+		ContentType string
+		Filename    string
+		Expected    string
+	}{
+		{"decompress-tgz/single.tar.gz", "", "", "tar.gz"},
+		{"decompress-tbz2/single.tar.bz2", "", "", "tar.bz2"},
+		{"decompress-txz/single.tar.xz", "", "", "tar.xz"},
+		{"decompress-tzst/single.tar.zst", "", "", "tar.zst"},
+		{"decompress-gz/single.gz", "", "", "gz"},
+		{"decompress-bz2/single.bz2", "", "", "bz2"},
+		{"decompress-xz/single.xz", "", "", "xz"},
+		{"decompress-zst/single.zst", "", "", "zst"},
+		{"decompress-zip/single.zip", "", "", "zip"},
+		{"decompress-tar/extended_header.tar", "", "", "tar"},
+		{"basic-file/foo.txt", "", "", ""},
+
+		// The content wins over the hints
+		{"decompress-zip/single.zip", "application/gzip", "single.tar.gz", "zip"},
+
+		// Hints are used for files without magic bytes
+		{"basic-file/foo.txt", "", "foo.tar", "tar"},
+		{"basic-file/foo.txt", "application/x-tar; charset=binary", "", "tar"},
+		{"basic-file/foo.txt", "text/plain", "foo.txt", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			// Copy the fixture to a path without an extension
+			src := filepath.Join(t.TempDir(), "download")
+			data, err := os.ReadFile(filepath.Join(fixtureDir, tc.Input))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if err := os.WriteFile(src, data, 0644); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			actual, err := sniffArchive(src, tc.ContentType, tc.Filename, Decompressors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if actual != tc.Expected {
+				t.Fatalf("bad: %q, expected %q", actual, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestGet_archiveAuto(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "download")
+	data, err := os.ReadFile(filepath.Join(fixtureDir, "decompress-tgz/single.tar.gz"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "target")
+	client := &Client{
+		Src:  fmtFileURL(src) + "?archive=auto",
+		Dst:  dst,
+		Mode: ClientModeDir,
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Decompressor != "tar.gz" {
+		t.Fatalf("bad decompressor: %q", result.Decompressor)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "file")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// A file that isn't an archive can't be a directory
+	client.Src = testModule("basic-file/foo.txt") + "?archive=auto"
+	client.Dst = filepath.Join(t.TempDir(), "target")
+	if err := client.Get(); err == nil {
+		t.Fatal("expected an error for a file that isn't an archive")
+	}
+
+	// but it is copied as is in file mode
+	client.Dst = filepath.Join(t.TempDir(), "foo")
+	client.Mode = ClientModeFile
+	result, err = client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Decompressor != "" {
+		t.Fatalf("bad decompressor: %q", result.Decompressor)
+	}
+	assertContents(t, client.Dst, "Hello\n")
+}
+
+func TestGet_archiveAutoHTTP(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	dst := t.TempDir()
+	client := &Client{
+		Src:  fmt.Sprintf("http://%s/attachment?archive=auto", ln.Addr()),
+		Dst:  dst,
+		Mode: ClientModeAny,
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.ContentType != "text/plain" || result.Filename != "hello.txt" {
+		t.Fatalf("bad content: %q %q", result.ContentType, result.Filename)
+	}
+
+	// The file isn't an archive, so it is named as the server suggests
+	assertContents(t, filepath.Join(dst, "hello.txt"), "Hello\n")
+}
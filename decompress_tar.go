@@ -5,27 +5,44 @@ package getter
 
 import (
 	"archive/tar"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
 )
 
-// untar is a shared helper for untarring an archive. The reader should provide
-// an uncompressed view of the tar archive.
-func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSizeLimit int64, filesLimit int) error {
+// untar is a shared helper for untarring an archive into dst, which is
+// written through fsys. The reader should provide an uncompressed view of
+// the tar archive. Only the entries selected by sel are unpacked. Symlink
+// entries are an error if disableSymlinks is set. observe is called with
+// the number of files written and their size once the archive is unpacked.
+func untar(ctx context.Context, fsys WritableFS, input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSizeLimit int64, filesLimit int, sel entrySelector, disableSymlinks bool, observe func(files int, bytes int64)) error {
 	tarR := tar.NewReader(input)
 	done := false
+	found := false
+	filter := sel.filter
 	dirHdrs := []*tar.Header{}
 	now := time.Now()
+	links := newArchiveLinks(fsys, dst, disableSymlinks)
 
 	var (
 		fileSize   int64
 		filesCount int
+
+		// written and writtenSize count the files written.
+		written     int
+		writtenSize int64
 	)
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if filesLimit > 0 {
 			filesCount++
 			if filesCount > filesLimit {
@@ -35,6 +52,9 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 
 		hdr, err := tarR.Next()
 		if err == io.EOF {
+			if sel.member != "" && !found {
+				return sel.notFound(src, dir)
+			}
 			if !done {
 				// Empty archive
 				return fmt.Errorf("empty archive: %s", src)
@@ -53,12 +73,86 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 
 		path := dst
 		if dir {
+			name, inMember := sel.dirName(hdr.Name, hdr.FileInfo().IsDir())
+			found = found || inMember
+			if name == "" {
+				// The archive isn't empty, the entry is stripped or not in
+				// the member.
+				done = true
+				continue
+			}
+			hdr.Name = name
+
 			// Disallow parent traversal
 			if containsDotDot(hdr.Name) {
 				return fmt.Errorf("entry contains '..': %s", hdr.Name)
 			}
 
 			path = filepath.Join(path, hdr.Name)
+
+			if filter != nil {
+				rel := filepath.ToSlash(filepath.Clean(hdr.Name))
+				if hdr.FileInfo().IsDir() {
+					// Directories are created with the files selected in
+					// them, and their attributes set if they were.
+					if filter.matchDir(rel) {
+						dirHdrs = append(dirHdrs, hdr)
+					}
+					continue
+				}
+				if !filter.Match(rel) {
+					// The archive isn't empty, it's filtered.
+					done = true
+					continue
+				}
+			}
+		} else if !sel.isFile(hdr.Name, hdr.FileInfo().IsDir()) {
+			continue
+		} else {
+			found = true
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			if !dir {
+				return fmt.Errorf("expected a single file, got link %s: %s", hdr.Name, src)
+			}
+			done = true
+
+			if hdr.Typeflag == tar.TypeSymlink {
+				if err := links.symlink(hdr.Name, hdr.Linkname, umask); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Hard links name an entry of the archive, which is stripped
+			// like the link is.
+			target, _ := sel.dirName(hdr.Linkname, false)
+			if filter != nil && target != "" && !filter.Match(filepath.ToSlash(filepath.Clean(target))) {
+				// The file the link points to isn't selected.
+				continue
+			}
+			if target == "" {
+				return fmt.Errorf("hard link %s points to %s, which isn't extracted", hdr.Name, hdr.Linkname)
+			}
+			size, err := links.hardLink(ctx, hdr.Name, target, umask)
+			if err != nil {
+				return err
+			}
+			written++
+			writtenSize += size
+			fileSize += size
+			if fileSizeLimit > 0 && fileSize > fileSizeLimit {
+				return fmt.Errorf("tar archive larger than limit: %d", fileSizeLimit)
+			}
+			continue
+		}
+
+		if dir {
+			if err := links.prepare(hdr.Name); err != nil {
+				return err
+			}
 		}
 
 		fileInfo := hdr.FileInfo()
@@ -75,7 +169,7 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 			}
 
 			// A directory, just make the directory and continue unarchiving...
-			if err := os.MkdirAll(path, mode(0755, umask)); err != nil {
+			if err := fsys.MkdirAll(path, mode(0755, umask)); err != nil {
 				return err
 			}
 
@@ -90,8 +184,8 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 			dstPath := filepath.Dir(path)
 
 			// Check that the directory exists, otherwise create it
-			if _, err := os.Stat(dstPath); os.IsNotExist(err) {
-				if err := os.MkdirAll(dstPath, mode(0755, umask)); err != nil {
+			if _, err := fsys.Lstat(dstPath); errors.Is(err, fs.ErrNotExist) {
+				if err := fsys.MkdirAll(dstPath, mode(0755, umask)); err != nil {
 					return err
 				}
 			}
@@ -106,10 +200,12 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 		done = true
 
 		// Size limit is tracked using the returned file info.
-		err = copyReader(path, tarR, hdr.FileInfo().Mode(), umask, 0)
+		err = copyReader(ctx, fsys, path, tarR, hdr.FileInfo().Mode(), umask, 0)
 		if err != nil {
 			return err
 		}
+		written++
+		writtenSize += fileInfo.Size()
 
 		// Set the access and modification time if valid, otherwise default to current time
 		aTime := now
@@ -120,7 +216,7 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 		if hdr.ModTime.Unix() > 0 {
 			mTime = hdr.ModTime
 		}
-		if err := os.Chtimes(path, aTime, mTime); err != nil {
+		if err := fsys.Chtimes(path, aTime, mTime); err != nil {
 			return err
 		}
 	}
@@ -128,8 +224,14 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 	// Perform a final pass over extracted directories to update metadata
 	for _, dirHdr := range dirHdrs {
 		path := filepath.Join(dst, dirHdr.Name)
+		if filter != nil {
+			// Skip the directories no selected file is in.
+			if _, err := fsys.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+		}
 		// Chmod the directory since they might be created before we know the mode flags
-		if err := os.Chmod(path, mode(dirHdr.FileInfo().Mode(), umask)); err != nil {
+		if err := fsys.Chmod(path, mode(dirHdr.FileInfo().Mode(), umask)); err != nil {
 			return err
 		}
 		// Set the mtime/atime attributes since they would have been changed during extraction
@@ -141,11 +243,12 @@ func untar(input io.Reader, dst, src string, dir bool, umask os.FileMode, fileSi
 		if dirHdr.ModTime.Unix() > 0 {
 			mTime = dirHdr.ModTime
 		}
-		if err := os.Chtimes(path, aTime, mTime); err != nil {
+		if err := fsys.Chtimes(path, aTime, mTime); err != nil {
 			return err
 		}
 	}
 
+	observe(written, writtenSize)
 	return nil
 }
 
@@ -166,21 +269,112 @@ type TarDecompressor struct {
 }
 
 func (d *TarDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *TarDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	return untarFile(ctx, req, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *TarDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	return untarStream(ctx, req, r, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
+
+// uncompressed returns a function that uncompresses the archive src as
+// it is read.
+func (d *TarDecompressor) uncompressed(src string) func(io.Reader) (io.ReadCloser, error) {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}
+}
+
+// untarFile unpacks the tar archive at req.Src, of which uncompressed
+// returns an uncompressed view, like the tar decompressors do.
+func untarFile(ctx context.Context, req *DecompressRequest, fileSizeLimit int64, filesLimit int, uncompressed func(io.Reader) (io.ReadCloser, error)) error {
+	// File first
+	f, err := os.Open(req.Src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	strip := req.StripComponents
+	if (req.Dir || req.Member != "") && strip == StripAuto {
+		if strip, err = tarRootStrip(ctx, f, uncompressed); err != nil {
+			return err
+		}
+	}
+
+	return untarReader(ctx, req, f, strip, fileSizeLimit, filesLimit, uncompressed)
+}
+
+// untarStream is untarFile for the archive read from r. StripAuto isn't
+// supported, since it needs to read the archive twice.
+func untarStream(ctx context.Context, req *DecompressRequest, r io.Reader, fileSizeLimit int64, filesLimit int, uncompressed func(io.Reader) (io.ReadCloser, error)) error {
+	if (req.Dir || req.Member != "") && req.StripComponents == StripAuto {
+		return fmt.Errorf("archive_strip=auto requires the archive in a file: %s", req.Src)
+	}
+	return untarReader(ctx, req, r, req.StripComponents, fileSizeLimit, filesLimit, uncompressed)
+}
+
+// untarReader unpacks the tar archive read from r, stripping strip
+// elements from the names of its entries.
+func untarReader(ctx context.Context, req *DecompressRequest, r io.Reader, strip int, fileSizeLimit int64, filesLimit int, uncompressed func(io.Reader) (io.ReadCloser, error)) error {
+	dst, src, dir, umask := req.Dst, req.Src, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	// If we're going into a directory we should make that first
 	mkdir := dst
 	if !dir {
 		mkdir = filepath.Dir(dst)
 	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(mkdir, mode(0755, umask)); err != nil {
 		return err
 	}
 
-	// File first
-	f, err := os.Open(src)
+	// Compression is second
+	ur, err := uncompressed(r)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = f.Close() }()
+	defer func() { _ = ur.Close() }()
+
+	return untar(ctx, fsys, ur, dst, src, dir, umask, fileSizeLimit, filesLimit, newEntrySelector(req, strip), req.DisableSymlinks, req.observe)
+}
+
+// tarRootStrip reads the tar archive in f to find the number of elements
+// to strip to unwrap its root directory, then rewinds f.
+func tarRootStrip(ctx context.Context, f *os.File, uncompressed func(io.Reader) (io.ReadCloser, error)) (int, error) {
+	r, err := uncompressed(f)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var root archiveRoot
+	tarR := tar.NewReader(r)
+	for !root.multiple {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		hdr, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader || hdr.Typeflag == tar.TypeXHeader {
+			continue
+		}
+		root.add(hdr.Name, hdr.FileInfo().IsDir())
+	}
 
-	return untar(f, dst, src, dir, umask, d.FileSizeLimit, d.FilesLimit)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return root.strip(), nil
 }
@@ -5,8 +5,9 @@ package getter
 
 import (
 	"compress/bzip2"
+	"context"
+	"io"
 	"os"
-	"path/filepath"
 )
 
 // TarBzip2Decompressor is an implementation of Decompressor that can
@@ -26,23 +27,23 @@ type TarBzip2Decompressor struct {
 }
 
 func (d *TarBzip2Decompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
-	// If we're going into a directory we should make that first
-	mkdir := dst
-	if !dir {
-		mkdir = filepath.Dir(dst)
-	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
-		return err
-	}
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
+// DecompressContext implements DecompressorContext.
+func (d *TarBzip2Decompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	return untarFile(ctx, req, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *TarBzip2Decompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	return untarStream(ctx, req, r, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	// Bzip2 compression is second
-	bzipR := bzip2.NewReader(f)
-	return untar(bzipR, dst, src, dir, umask, d.FileSizeLimit, d.FilesLimit)
+// uncompressed returns a function that uncompresses the archive src as
+// it is read.
+func (d *TarBzip2Decompressor) uncompressed(src string) func(io.Reader) (io.ReadCloser, error) {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	}
 }
@@ -4,6 +4,17 @@
 package getter
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +62,464 @@ func TestLimitedDecompressors(t *testing.T) {
 	checkFileSizeLimit(decompressors["xz"].(*XzDecompressor).FileSizeLimit)
 	checkFileSizeLimit(decompressors["zst"].(*ZstdDecompressor).FileSizeLimit)
 }
+
+func TestDecompressContext_cancel(t *testing.T) {
+	cases := []struct {
+		Key string
+		Src string
+		Dir bool
+	}{
+		{"tar.gz", "decompress-tgz/multiple.tar.gz", true},
+		{"zip", "decompress-zip/multiple.zip", true},
+		{"gz", "decompress-gz/single.gz", false},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range cases {
+		t.Run(tc.Key, func(t *testing.T) {
+			d, ok := Decompressors[tc.Key].(DecompressorContext)
+			if !ok {
+				t.Fatalf("%s does not implement DecompressorContext", tc.Key)
+			}
+
+			err := d.DecompressContext(ctx, &DecompressRequest{
+				Dst: filepath.Join(t.TempDir(), "dst"),
+				Src: filepath.Join(fixtureDir, tc.Src),
+				Dir: tc.Dir,
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+// testArchives writes a tar.gz and a zip archive with the given entries,
+// where names ending with a slash are directories, and returns their paths
+// by decompressor key. Entries like "name -> target" are symlinks, and
+// "name => target" hard links, which the zip archive leaves out.
+func testArchives(t *testing.T, names ...string) map[string]string {
+	t.Helper()
+
+	dir := t.TempDir()
+	tgzPath, zipPath := filepath.Join(dir, "a.tar.gz"), filepath.Join(dir, "a.zip")
+	tgzF, err := os.Create(tgzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tgzF.Close() }()
+	zipF, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zipF.Close() }()
+
+	gzipW := gzip.NewWriter(tgzF)
+	tarW := tar.NewWriter(gzipW)
+	zipW := zip.NewWriter(zipF)
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(name))}
+		if strings.HasSuffix(name, "/") {
+			hdr.Mode, hdr.Typeflag, hdr.Size = 0755, tar.TypeDir, 0
+		}
+		if link, target, ok := strings.Cut(name, " -> "); ok {
+			hdr.Name, hdr.Linkname, hdr.Mode, hdr.Typeflag, hdr.Size = link, target, 0777, tar.TypeSymlink, 0
+		}
+		if link, target, ok := strings.Cut(name, " => "); ok {
+			hdr.Name, hdr.Linkname, hdr.Typeflag, hdr.Size = link, target, tar.TypeLink, 0
+		}
+		if err := tarW.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			continue
+		}
+
+		fh := &zip.FileHeader{Name: hdr.Name, Method: zip.Deflate}
+		fh.SetMode(hdr.FileInfo().Mode())
+		w, err := zipW.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			if _, err := w.Write([]byte(hdr.Linkname)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tarW.Write([]byte(name)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, c := range []interface{ Close() error }{tarW, gzipW, zipW} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return map[string]string{"tar.gz": tgzPath, "zip": zipPath}
+}
+
+func TestDecompressContext_strip(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Entries []string
+		Strip   int
+		Files   []string
+		Err     string
+	}{
+		{
+			"none",
+			[]string{"proj-1.0/", "proj-1.0/main.tf", "proj-1.0/sub/a"},
+			0,
+			[]string{"proj-1.0/main.tf", "proj-1.0/sub/a"},
+			"",
+		},
+		{
+			"one",
+			[]string{"proj-1.0/", "proj-1.0/main.tf", "proj-1.0/sub/a"},
+			1,
+			[]string{"main.tf", "sub/a"},
+			"",
+		},
+		{
+			"two",
+			[]string{"proj-1.0/", "proj-1.0/main.tf", "proj-1.0/sub/a"},
+			2,
+			[]string{"a"},
+			"",
+		},
+		{
+			"auto",
+			[]string{"./proj-1.0/", "./proj-1.0/main.tf", "./proj-1.0/sub/a"},
+			StripAuto,
+			[]string{"main.tf", "sub/a"},
+			"",
+		},
+		{
+			"auto implied root",
+			[]string{"proj-1.0/main.tf", "proj-1.0/sub/a"},
+			StripAuto,
+			[]string{"main.tf", "sub/a"},
+			"",
+		},
+		{
+			"auto multiple roots",
+			[]string{"proj-1.0/main.tf", "README.md"},
+			StripAuto,
+			[]string{"README.md", "proj-1.0/main.tf"},
+			"",
+		},
+		{
+			"auto single file",
+			[]string{"main.tf"},
+			StripAuto,
+			[]string{"main.tf"},
+			"",
+		},
+		{
+			"traversal",
+			[]string{"proj-1.0/main.tf", "proj-1.0/../../evil"},
+			1,
+			nil,
+			"entry contains '..'",
+		},
+	}
+
+	for _, tc := range cases {
+		for key, src := range testArchives(t, tc.Entries...) {
+			t.Run(tc.Name+"/"+key, func(t *testing.T) {
+				dst := filepath.Join(t.TempDir(), "dst")
+				err := Decompressors[key].(DecompressorContext).DecompressContext(context.Background(), &DecompressRequest{
+					Dst:             dst,
+					Src:             src,
+					Dir:             true,
+					StripComponents: tc.Strip,
+				})
+				if tc.Err != "" {
+					if err == nil || !strings.Contains(err.Error(), tc.Err) {
+						t.Fatalf("expected error containing %q, got %v", tc.Err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				if files := testListFiles(t, dst); !reflect.DeepEqual(files, tc.Files) {
+					t.Fatalf("bad files: %v", files)
+				}
+			})
+		}
+	}
+}
+
+func TestDecompressContext_member(t *testing.T) {
+	entries := []string{"proj-1.0/", "proj-1.0/main.tf", "proj-1.0/bin/", "proj-1.0/bin/tool", "proj-1.0/bin/lib/a", "proj-1.0/binary"}
+	cases := []struct {
+		Name   string
+		Member string
+		Dir    bool
+		Strip  int
+		Files  []string
+		Err    error
+	}{
+		{"dir", "proj-1.0/bin", true, 0, []string{"lib/a", "tool"}, nil},
+		{"dir stripped", "bin", true, StripAuto, []string{"lib/a", "tool"}, nil},
+		{"dir file", "proj-1.0/bin/tool", true, 0, []string{"tool"}, nil},
+		{"file", "./proj-1.0/bin/tool", false, 0, nil, nil},
+		{"dir missing", "proj-1.0/missing", true, 0, nil, ErrSubdirNotFound},
+		{"file missing", "proj-1.0/bin", false, 0, nil, ErrNotFound},
+	}
+
+	for _, tc := range cases {
+		for key, src := range testArchives(t, entries...) {
+			t.Run(tc.Name+"/"+key, func(t *testing.T) {
+				dst := filepath.Join(t.TempDir(), "dst")
+				err := Decompressors[key].(DecompressorContext).DecompressContext(context.Background(), &DecompressRequest{
+					Dst:             dst,
+					Src:             src,
+					Dir:             tc.Dir,
+					StripComponents: tc.Strip,
+					Member:          tc.Member,
+				})
+				if tc.Err != nil {
+					if !errors.Is(err, tc.Err) {
+						t.Fatalf("expected %v, got %v", tc.Err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				if !tc.Dir {
+					assertContents(t, dst, "proj-1.0/bin/tool")
+					return
+				}
+				if files := testListFiles(t, dst); !reflect.DeepEqual(files, tc.Files) {
+					t.Fatalf("bad files: %v", files)
+				}
+			})
+		}
+	}
+}
+
+func TestDecompressStream(t *testing.T) {
+	cases := []struct {
+		Key   string
+		Input string
+		Dir   bool
+	}{
+		{"tar", "decompress-tar/extended_header.tar", true},
+		{"tar.gz", "decompress-tgz/multiple_dir.tar.gz", true},
+		{"tar.bz2", "decompress-tbz2/multiple.tar.bz2", true},
+		{"tar.xz", "decompress-txz/multiple_dir.tar.xz", true},
+		{"tar.zst", "decompress-tzst/multiple_dir.tar.zst", true},
+		{"gz", "decompress-gz/single.gz", false},
+		{"bz2", "decompress-bz2/single.bz2", false},
+		{"xz", "decompress-xz/single.xz", false},
+		{"zst", "decompress-zst/single.zst", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Key, func(t *testing.T) {
+			src := filepath.Join(fixtureDir, tc.Input)
+			expected := filepath.Join(t.TempDir(), "expected")
+			err := decompress(context.Background(), Decompressors[tc.Key], &DecompressRequest{Dst: expected, Src: src, Dir: tc.Dir})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			f, err := os.Open(src)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			defer func() { _ = f.Close() }()
+
+			// Hide the file behind a plain reader, which can't seek
+			dst := filepath.Join(t.TempDir(), "dst")
+			sd := Decompressors[tc.Key].(StreamDecompressor)
+			err = sd.DecompressStream(context.Background(), &DecompressRequest{Dst: dst, Src: tc.Input, Dir: tc.Dir}, struct{ io.Reader }{f})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if !tc.Dir {
+				if testMD5(t, dst) != testMD5(t, expected) {
+					t.Fatal("streamed file differs")
+				}
+				return
+			}
+			if files := testListFiles(t, dst); !reflect.DeepEqual(files, testListFiles(t, expected)) {
+				t.Fatalf("bad files: %v", files)
+			}
+		})
+	}
+
+	// The root directory can't be detected without reading twice
+	f, err := os.Open(filepath.Join(fixtureDir, "decompress-tgz/multiple_dir.tar.gz"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer func() { _ = f.Close() }()
+	err = Decompressors["tar.gz"].(StreamDecompressor).DecompressStream(context.Background(), &DecompressRequest{
+		Dst:             t.TempDir(),
+		Src:             "multiple_dir.tar.gz",
+		Dir:             true,
+		StripComponents: StripAuto,
+	}, f)
+	if err == nil {
+		t.Fatal("expected an error for archive_strip=auto")
+	}
+}
+
+func TestDecompressContext_links(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+
+	cases := []struct {
+		Name    string
+		Entries []string
+		Strip   int
+		Disable bool
+		Err     string
+	}{
+		{"links", []string{"a/", "a/file", "a/link -> file", "a/up -> ../a/file", "hard => a/file"}, 0, false, ""},
+		{"links stripped", []string{"root/", "root/a/", "root/a/file", "root/a/link -> file", "root/a/up -> ../a/file", "root/hard => root/a/file"}, StripAuto, false, ""},
+		{"absolute symlink", []string{"file", "link -> /etc/passwd"}, 0, false, "outside of the destination"},
+		{"parent symlink", []string{"file", "link -> ../file"}, 0, false, "outside of the destination"},
+		{"symlink through symlink", []string{"file", "dot -> .", "link -> dot/../file"}, 0, false, "outside of the destination"},
+		{"write through symlink", []string{"a/", "link -> a", "link/file"}, 0, false, "inside symlink"},
+		{"hard link outside", []string{"file", "hard => ../file"}, 0, false, "outside of the destination"},
+		{"disabled", []string{"file", "link -> file"}, 0, true, "copying of symlinks has been disabled"},
+	}
+
+	for _, tc := range cases {
+		for key, src := range testArchives(t, tc.Entries...) {
+			t.Run(tc.Name+"/"+key, func(t *testing.T) {
+				dst := filepath.Join(t.TempDir(), "dst")
+				err := Decompressors[key].(DecompressorContext).DecompressContext(context.Background(), &DecompressRequest{
+					Dst:             dst,
+					Src:             src,
+					Dir:             true,
+					StripComponents: tc.Strip,
+					DisableSymlinks: tc.Disable,
+				})
+				if tc.Err != "" {
+					if key == "zip" && strings.HasPrefix(tc.Name, "hard") {
+						// The zip archive has no hard links.
+						return
+					}
+					if err == nil || !strings.Contains(err.Error(), tc.Err) {
+						t.Fatalf("expected an error containing %q, got %v", tc.Err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+
+				for link, target := range map[string]string{"a/link": "file", "a/up": "../a/file"} {
+					actual, err := os.Readlink(filepath.Join(dst, link))
+					if err != nil {
+						t.Fatalf("err: %s", err)
+					}
+					if actual != target {
+						t.Fatalf("bad target of %s: %s", link, actual)
+					}
+				}
+				// Files contain their name in the archive
+				expected := tc.Entries[len(tc.Entries)-4]
+				assertContents(t, filepath.Join(dst, "a", "up"), expected)
+				if key == "tar.gz" {
+					assertContents(t, filepath.Join(dst, "hard"), expected)
+
+					// Hard links are real on the OS filesystem
+					fi1, err := os.Stat(filepath.Join(dst, "hard"))
+					if err != nil {
+						t.Fatalf("err: %s", err)
+					}
+					fi2, err := os.Stat(filepath.Join(dst, "a", "file"))
+					if err != nil {
+						t.Fatalf("err: %s", err)
+					}
+					if !os.SameFile(fi1, fi2) {
+						t.Fatal("expected a hard link")
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestDecompressContext_existingSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+
+	// Symlinks already in the destination, such as the ones of an archive
+	// extracted there before, are never written or linked through.
+	first := testArchives(t, "a -> .")
+	cases := []struct {
+		Name    string
+		Entries []string
+	}{
+		{"write", []string{"a/b/x -> ../..", "b/x/pwned"}},
+		{"file", []string{"a/pwned"}},
+		{"hard link", []string{"file", "hard => a/file"}},
+	}
+
+	for _, tc := range cases {
+		for key, src := range testArchives(t, tc.Entries...) {
+			if key == "zip" && strings.HasPrefix(tc.Name, "hard") {
+				// The zip archive has no hard links.
+				continue
+			}
+			t.Run(tc.Name+"/"+key, func(t *testing.T) {
+				root := t.TempDir()
+				dst := filepath.Join(root, "dst")
+				for _, src := range []string{first[key], src, src} {
+					err := decompress(context.Background(), Decompressors[key], &DecompressRequest{Dst: dst, Src: src, Dir: true})
+					if src == first[key] && err != nil {
+						t.Fatalf("err: %s", err)
+					}
+					if src != first[key] && (err == nil || !strings.Contains(err.Error(), "inside symlink")) {
+						t.Fatalf("expected an error about the symlink, got %v", err)
+					}
+				}
+
+				if _, err := os.Lstat(filepath.Join(root, "pwned")); !os.IsNotExist(err) {
+					t.Fatalf("expected nothing to be written outside of the destination: %v", err)
+				}
+				if _, err := os.Lstat(filepath.Join(dst, "b")); !os.IsNotExist(err) {
+					t.Fatalf("expected nothing to be written through the symlink: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestDecompressContext_replaceSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+
+	// A file after a symlink of the same name replaces the symlink rather
+	// than being written through it.
+	for key, src := range testArchives(t, "file", "link -> file", "link") {
+		t.Run(key, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			err := decompress(context.Background(), Decompressors[key], &DecompressRequest{Dst: dst, Src: src, Dir: true})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			assertContents(t, filepath.Join(dst, "file"), "file")
+			assertContents(t, filepath.Join(dst, "link"), "link")
+		})
+	}
+}
@@ -5,9 +5,10 @@ package getter
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 )
 
 // TarGzipDecompressor is an implementation of Decompressor that can
@@ -27,28 +28,27 @@ type TarGzipDecompressor struct {
 }
 
 func (d *TarGzipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
-	// If we're going into a directory we should make that first
-	mkdir := dst
-	if !dir {
-		mkdir = filepath.Dir(dst)
-	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
-		return err
-	}
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
+// DecompressContext implements DecompressorContext.
+func (d *TarGzipDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	return untarFile(ctx, req, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	// Gzip compression is second
-	gzipR, err := gzip.NewReader(f)
-	if err != nil {
-		return fmt.Errorf("Error opening a gzip reader for %s: %w", src, err)
-	}
-	defer func() { _ = gzipR.Close() }()
+// DecompressStream implements StreamDecompressor.
+func (d *TarGzipDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	return untarStream(ctx, req, r, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	return untar(gzipR, dst, src, dir, umask, d.FileSizeLimit, d.FilesLimit)
+// uncompressed returns a function that uncompresses the archive src as
+// it is read.
+func (d *TarGzipDecompressor) uncompressed(src string) func(io.Reader) (io.ReadCloser, error) {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		gzipR, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening a gzip reader for %s: %w", src, err)
+		}
+		return gzipR, nil
+	}
 }
@@ -5,9 +5,10 @@ package getter
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/ulikunitz/xz"
 )
@@ -29,27 +30,27 @@ type TarXzDecompressor struct {
 }
 
 func (d *TarXzDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
-	// If we're going into a directory we should make that first
-	mkdir := dst
-	if !dir {
-		mkdir = filepath.Dir(dst)
-	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
-		return err
-	}
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
+// DecompressContext implements DecompressorContext.
+func (d *TarXzDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	return untarFile(ctx, req, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	// xz compression is second
-	txzR, err := xz.NewReader(bufio.NewReader(f))
-	if err != nil {
-		return fmt.Errorf("Error opening an xz reader for %s: %w", src, err)
-	}
+// DecompressStream implements StreamDecompressor.
+func (d *TarXzDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	return untarStream(ctx, req, r, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	return untar(txzR, dst, src, dir, umask, d.FileSizeLimit, d.FilesLimit)
+// uncompressed returns a function that uncompresses the archive src as
+// it is read.
+func (d *TarXzDecompressor) uncompressed(src string) func(io.Reader) (io.ReadCloser, error) {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		txzR, err := xz.NewReader(bufio.NewReader(r))
+		if err != nil {
+			return nil, fmt.Errorf("Error opening an xz reader for %s: %w", src, err)
+		}
+		return io.NopCloser(txzR), nil
+	}
 }
@@ -4,9 +4,10 @@
 package getter
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/klauspost/compress/zstd"
 )
@@ -28,28 +29,27 @@ type TarZstdDecompressor struct {
 }
 
 func (d *TarZstdDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
-	// If we're going into a directory we should make that first
-	mkdir := dst
-	if !dir {
-		mkdir = filepath.Dir(dst)
-	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
-		return err
-	}
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
+// DecompressContext implements DecompressorContext.
+func (d *TarZstdDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	return untarFile(ctx, req, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	// Zstd compression is second
-	zstdR, err := zstd.NewReader(f)
-	if err != nil {
-		return fmt.Errorf("Error opening a zstd reader for %s: %w", src, err)
-	}
-	defer zstdR.Close()
+// DecompressStream implements StreamDecompressor.
+func (d *TarZstdDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	return untarStream(ctx, req, r, d.FileSizeLimit, d.FilesLimit, d.uncompressed(req.Src))
+}
 
-	return untar(zstdR, dst, src, dir, umask, d.FileSizeLimit, d.FilesLimit)
+// uncompressed returns a function that uncompresses the archive src as
+// it is read.
+func (d *TarZstdDecompressor) uncompressed(src string) func(io.Reader) (io.ReadCloser, error) {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		zstdR, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening a zstd reader for %s: %w", src, err)
+		}
+		return zstdR.IOReadCloser(), nil
+	}
 }
@@ -5,7 +5,9 @@ package getter
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -22,29 +24,51 @@ type XzDecompressor struct {
 }
 
 func (d *XzDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *XzDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	// File first
+	f, err := os.Open(req.Src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return d.DecompressStream(ctx, req, f)
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *XzDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	dst, dir, umask := req.Dst, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	// Directory isn't supported at all
 	if dir {
 		return fmt.Errorf("xz-compressed files can only unarchive to a single file")
 	}
 
 	// If we're going into a directory we should make that first
-	if err := os.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
 		return err
 	}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
 	// xz compression is second
-	xzR, err := xz.NewReader(bufio.NewReader(f))
+	xzR, err := xz.NewReader(bufio.NewReader(r))
 	if err != nil {
 		return err
 	}
 
 	// Copy it out, potentially using a file size limit.
-	return copyReader(dst, xzR, 0622, umask, d.FileSizeLimit)
+	return copyDecompressed(ctx, fsys, req, xzR, d.FileSizeLimit)
+}
+
+// DecompressReader implements ReaderDecompressor.
+func (d *XzDecompressor) DecompressReader(r io.Reader) (io.ReadCloser, error) {
+	xzR, err := xz.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return decompressedReader(xzR, d.FileSizeLimit, nil), nil
 }
@@ -5,9 +5,15 @@ package getter
 
 import (
 	"archive/zip"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // ZipDecompressor is an implementation of Decompressor that can
@@ -27,12 +33,20 @@ type ZipDecompressor struct {
 }
 
 func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *ZipDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	dst, src, dir, umask := req.Dst, req.Src, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	// If we're going into a directory we should make that first
 	mkdir := dst
 	if !dir {
 		mkdir = filepath.Dir(dst)
 	}
-	if err := os.MkdirAll(mkdir, mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(mkdir, mode(0755, umask)); err != nil {
 		return err
 	}
 
@@ -48,7 +62,7 @@ func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMod
 		// Empty archive
 		return fmt.Errorf("empty archive: %s", src)
 	}
-	if !dir && len(zipR.File) > 1 {
+	if !dir && req.Member == "" && len(zipR.File) > 1 {
 		return fmt.Errorf("expected a single file: %s", src)
 	}
 
@@ -56,18 +70,69 @@ func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMod
 		return fmt.Errorf("zip archive contains too many files: %d > %d", len(zipR.File), d.FilesLimit)
 	}
 
+	strip := req.StripComponents
+	if strip == StripAuto {
+		var root archiveRoot
+		for _, f := range zipR.File {
+			root.add(f.Name, f.FileInfo().IsDir())
+		}
+		strip = root.strip()
+	}
+	sel := newEntrySelector(req, strip)
+	found := false
+	links := newArchiveLinks(fsys, dst, req.DisableSymlinks)
+	dirs := []zipDir{}
+	now := time.Now()
+
 	var fileSizeTotal int64
 
+	// written and writtenSize count the files written.
+	var (
+		written     int
+		writtenSize int64
+	)
+
 	// Go through and unarchive
 	for _, f := range zipR.File {
-		path := dst
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// name is the path of the entry relative to dst in dir mode.
+		path, name := dst, ""
 		if dir {
+			var inMember bool
+			name, inMember = sel.dirName(f.Name, f.FileInfo().IsDir())
+			found = found || inMember
+			if name == "" {
+				continue
+			}
+
 			// Disallow parent traversal
-			if containsDotDot(f.Name) {
-				return fmt.Errorf("entry contains '..': %s", f.Name)
+			if containsDotDot(name) {
+				return fmt.Errorf("entry contains '..': %s", name)
 			}
 
-			path = filepath.Join(path, f.Name)
+			path = filepath.Join(path, name)
+
+			// Only the directories of selected files are created, and
+			// their attributes set if they were.
+			if req.Filter != nil {
+				rel := filepath.ToSlash(filepath.Clean(name))
+				if f.FileInfo().IsDir() {
+					if req.Filter.matchDir(rel) {
+						dirs = append(dirs, zipDir{path: path, f: f})
+					}
+					continue
+				}
+				if !req.Filter.Match(rel) {
+					continue
+				}
+			}
+		} else if !sel.isFile(f.Name, f.FileInfo().IsDir()) {
+			continue
+		} else {
+			found = true
 		}
 
 		fileInfo := f.FileInfo()
@@ -84,10 +149,31 @@ func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMod
 			}
 
 			// A directory, just make the directory and continue unarchiving...
-			if err := os.MkdirAll(path, mode(0755, umask)); err != nil {
+			if err := links.prepare(name); err != nil {
+				return err
+			}
+			if err := fsys.MkdirAll(path, mode(0755, umask)); err != nil {
 				return err
 			}
 
+			// Record the directory so that its attributes are set after
+			// the files in it are extracted
+			dirs = append(dirs, zipDir{path: path, f: f})
+
+			continue
+		}
+
+		if fileInfo.Mode()&os.ModeSymlink != 0 {
+			if !dir {
+				return fmt.Errorf("expected a single file, got link %s: %s", f.Name, src)
+			}
+			target, err := readZipSymlink(f)
+			if err != nil {
+				return err
+			}
+			if err := links.symlink(name, target, umask); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -95,7 +181,10 @@ func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMod
 		// required to contain entries for just the directories so this
 		// can happen.
 		if dir {
-			if err := os.MkdirAll(filepath.Dir(path), mode(0755, umask)); err != nil {
+			if err := links.prepare(name); err != nil {
+				return err
+			}
+			if err := fsys.MkdirAll(filepath.Dir(path), mode(0755, umask)); err != nil {
 				return err
 			}
 		}
@@ -110,12 +199,124 @@ func (d *ZipDecompressor) Decompress(dst, src string, dir bool, umask os.FileMod
 		}
 
 		// Size limit is tracked using the returned file info.
-		err = copyReader(path, srcF, f.Mode(), umask, 0)
+		err = copyReader(ctx, fsys, path, srcF, f.Mode(), umask, 0)
 		_ = srcF.Close()
 		if err != nil {
 			return err
 		}
+		written++
+		writtenSize += fileInfo.Size()
+
+		// Set the modification time, the access time isn't recorded
+		if err := fsys.Chtimes(path, now, zipModTime(f, now)); err != nil {
+			return err
+		}
+	}
+
+	if sel.member != "" && !found {
+		return sel.notFound(src, dir)
 	}
 
+	// Perform a final pass over extracted directories to update metadata
+	for _, d := range dirs {
+		if req.Filter != nil {
+			// Skip the directories no selected file is in.
+			if _, err := fsys.Lstat(d.path); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+		}
+		// Chmod the directory since they are created before we know the
+		// mode flags, and set the mtime that extracting changed.
+		if err := fsys.Chmod(d.path, mode(zipDirMode(d.f), umask)); err != nil {
+			return err
+		}
+		if err := fsys.Chtimes(d.path, now, zipModTime(d.f, now)); err != nil {
+			return err
+		}
+	}
+
+	req.observe(written, writtenSize)
 	return nil
 }
+
+// maxZipSymlink is the maximum length of the target of a zip symlink.
+const maxZipSymlink = 4096
+
+// readZipSymlink returns the target of the symlink f, which is its
+// content.
+func readZipSymlink(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+
+	target, err := io.ReadAll(io.LimitReader(r, maxZipSymlink+1))
+	if err != nil {
+		return "", err
+	}
+	if len(target) > maxZipSymlink {
+		return "", fmt.Errorf("symlink %s is too long", f.Name)
+	}
+	return string(target), nil
+}
+
+// zipDir is a directory entry of a zip archive, extracted to path.
+type zipDir struct {
+	path string
+	f    *zip.File
+}
+
+// The creators of zip archives whose external attributes hold Unix modes,
+// as found in the upper byte of CreatorVersion.
+const (
+	zipCreatorUnix  = 3
+	zipCreatorMacOS = 19
+)
+
+// zipDirMode returns the mode of the directory f. Only archives written on
+// Unix and macOS record one. For others, archive/zip makes a mode up from
+// the MS-DOS attributes, which lacks the execute bits, so the directory
+// gets the default mode instead.
+func zipDirMode(f *zip.File) os.FileMode {
+	switch f.CreatorVersion >> 8 {
+	case zipCreatorUnix, zipCreatorMacOS:
+		return f.Mode()
+	}
+	return 0755
+}
+
+// zipModTime returns the modification time of f, or now if it has none.
+// archive/zip reads it from the extended timestamp fields if there are
+// any. Otherwise it is the MS-DOS time of the entry, which is the local
+// time of the system that wrote the archive, like unzip assumes. A zero
+// MS-DOS date, which is before 1980, means there is no time.
+func zipModTime(f *zip.File, now time.Time) time.Time {
+	m := f.Modified
+	if hasZipExtendedTime(f.Extra) {
+		return m
+	}
+	if m.Year() < 1980 {
+		return now
+	}
+	return time.Date(m.Year(), m.Month(), m.Day(), m.Hour(), m.Minute(), m.Second(), 0, time.Local)
+}
+
+// hasZipExtendedTime reports whether the extra fields of a zip entry have
+// one of the timestamps archive/zip reads: NTFS, extended timestamp or
+// Info-ZIP Unix.
+func hasZipExtendedTime(extra []byte) bool {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		switch id {
+		case 0x000a, 0x5455, 0x5855:
+			return true
+		}
+		if len(extra) < 4+size {
+			break
+		}
+		extra = extra[4+size:]
+	}
+	return false
+}
@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestZipDecompressor(t *testing.T) {
@@ -199,3 +200,107 @@ func TestDecompressZipBomb(t *testing.T) {
 		}
 	})
 }
+
+func TestDecompressZipTimes(t *testing.T) {
+	dirTime := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	fileTime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	entries := []*zip.FileHeader{
+		{Name: "dir/", Modified: dirTime},
+		{Name: "dir/file", Modified: fileTime, Method: zip.Deflate},
+		// Only an MS-DOS time, for 2020-01-02 03:04:06 local time
+		{Name: "dos", ModifiedDate: 40<<9 | 1<<5 | 2, ModifiedTime: 3<<11 | 4<<5 | 3},
+	}
+	entries[0].SetMode(os.ModeDir | 0700)
+	entries[1].SetMode(0750)
+	entries[2].SetMode(0644)
+	for _, fh := range entries {
+		if _, err := w.CreateHeader(fh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(t.TempDir(), "input.zip")
+	if err := os.WriteFile(src, buf.Bytes(), 0666); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	dst := t.TempDir()
+	if err := new(ZipDecompressor).Decompress(dst, src, true, 0); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]time.Time{
+		"dir":      dirTime,
+		"dir/file": fileTime,
+		"dos":      time.Date(2020, 1, 2, 3, 4, 6, 0, time.Local),
+	}
+	for name, mtime := range expected {
+		fi, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !fi.ModTime().Equal(mtime) {
+			t.Fatalf("expected mtime %s for %s, got %s", mtime, name, fi.ModTime())
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		for name, perm := range map[string]os.FileMode{"dir": 0700, "dir/file": 0750} {
+			fi, err := os.Stat(filepath.Join(dst, name))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if fi.Mode().Perm() != perm {
+				t.Fatalf("expected mode %s for %s, got %s", perm, name, fi.Mode().Perm())
+			}
+		}
+	}
+}
+
+func TestDecompressZipDirModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("modes are not supported on windows")
+	}
+
+	// Create records no Unix mode, so the directory mode archive/zip
+	// reports is made up from MS-DOS attributes.
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, name := range []string{"dos/", "dos/file"} {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fh := &zip.FileHeader{Name: "unix/"}
+	fh.SetMode(os.ModeDir | 0700)
+	if _, err := w.CreateHeader(fh); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(t.TempDir(), "input.zip")
+	if err := os.WriteFile(src, buf.Bytes(), 0666); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	dst := t.TempDir()
+	if err := new(ZipDecompressor).Decompress(dst, src, true, 0022); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for name, perm := range map[string]os.FileMode{"dos": 0755, "unix": 0700} {
+		fi, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if fi.Mode().Perm() != perm {
+			t.Fatalf("expected mode %s for %s, got %s", perm, name, fi.Mode().Perm())
+		}
+	}
+}
@@ -4,7 +4,9 @@
 package getter
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -21,29 +23,54 @@ type ZstdDecompressor struct {
 }
 
 func (d *ZstdDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.DecompressContext(context.Background(), &DecompressRequest{Dst: dst, Src: src, Dir: dir, Umask: umask})
+}
+
+// DecompressContext implements DecompressorContext.
+func (d *ZstdDecompressor) DecompressContext(ctx context.Context, req *DecompressRequest) error {
+	// File first
+	f, err := os.Open(req.Src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return d.DecompressStream(ctx, req, f)
+}
+
+// DecompressStream implements StreamDecompressor.
+func (d *ZstdDecompressor) DecompressStream(ctx context.Context, req *DecompressRequest, r io.Reader) error {
+	dst, dir, umask := req.Dst, req.Dir, req.Umask
+	fsys := destFS(req.FS)
+
 	if dir {
 		return fmt.Errorf("zstd-compressed files can only unarchive to a single file")
 	}
 
 	// If we're going into a directory we should make that first
-	if err := os.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), mode(0755, umask)); err != nil {
 		return err
 	}
 
-	// File first
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
 	// zstd compression is second
-	zstdR, err := zstd.NewReader(f)
+	zstdR, err := zstd.NewReader(r)
 	if err != nil {
 		return err
 	}
 	defer zstdR.Close()
 
 	// Copy it out, potentially using a file size limit.
-	return copyReader(dst, zstdR, 0622, umask, d.FileSizeLimit)
+	return copyDecompressed(ctx, fsys, req, zstdR, d.FileSizeLimit)
+}
+
+// DecompressReader implements ReaderDecompressor.
+func (d *ZstdDecompressor) DecompressReader(r io.Reader) (io.ReadCloser, error) {
+	zstdR, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return decompressedReader(zstdR, d.FileSizeLimit, func() error {
+		zstdR.Close()
+		return nil
+	}), nil
 }
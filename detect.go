@@ -45,6 +45,14 @@ func init() {
 // This is safe to be called with an already valid source string: Detect
 // will just return it.
 func Detect(src string, pwd string, ds []Detector) (string, error) {
+	result, _, err := detect(src, pwd, ds)
+	return result, err
+}
+
+// detect is the implementation of Detect. It additionally returns the
+// Detector that rewrote the source, which is nil if src was already a
+// valid URL.
+func detect(src string, pwd string, ds []Detector) (string, Detector, error) {
 	getForce, getSrc := getForcedGetter(src)
 
 	// Separate out the subdir if there is one, we don't pass that to detect
@@ -53,57 +61,43 @@ func Detect(src string, pwd string, ds []Detector) (string, error) {
 	u, err := url.Parse(getSrc)
 	if err == nil && u.Scheme != "" {
 		// Valid URL
-		return src, nil
+		return src, nil, nil
 	}
 
 	for _, d := range ds {
 		result, ok, err := d.Detect(getSrc, pwd)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		if !ok {
 			continue
 		}
 
-		var detectForce string
-		detectForce, result = getForcedGetter(result)
-		result, detectSubdir := SourceDirSubdir(result)
+		ds, err := ParseSource(result)
+		if err != nil {
+			return "", nil, err
+		}
 
 		// If we have a subdir from the detection, then prepend it to our
 		// requested subdir.
-		if detectSubdir != "" {
+		if ds.Subdir != "" {
 			if subDir != "" {
-				subDir = filepath.Join(detectSubdir, subDir)
-			} else {
-				subDir = detectSubdir
+				ds.Subdir = filepath.Join(ds.Subdir, subDir)
 			}
-		}
-
-		if subDir != "" {
-			u, err := url.Parse(result)
-			if err != nil {
-				return "", fmt.Errorf("Error parsing URL: %w", err)
-			}
-			u.Path += "//" + subDir
-
-			// a subdir may contain wildcards, but in order to support them we
-			// have to ensure the path isn't escaped.
-			u.RawPath = u.Path
-
-			result = u.String()
+		} else {
+			ds.Subdir = subDir
 		}
 
 		// Preserve the forced getter if it exists. We try to use the
 		// original set force first, followed by any force set by the
 		// detector.
 		if getForce != "" {
-			result = fmt.Sprintf("%s::%s", getForce, result)
-		} else if detectForce != "" {
-			result = fmt.Sprintf("%s::%s", detectForce, result)
+			ds.Getter = getForce
 		}
 
-		return result, nil
+		result = ds.String()
+		return result, d, nil
 	}
 
-	return "", fmt.Errorf("invalid source string: %s", src)
+	return "", nil, fmt.Errorf("invalid source string: %s", src)
 }
@@ -35,6 +35,12 @@ func TestDetect(t *testing.T) {
 			"git::https://github.com/hashicorp/foo.git//bar",
 			false,
 		},
+		{
+			"github.com/hashicorp/foo?ref=v1&depth=1",
+			"",
+			"git::https://github.com/hashicorp/foo.git?ref=v1&depth=1",
+			false,
+		},
 		{
 			"git::https://github.com/hashicorp/consul.git",
 			"",
@@ -0,0 +1,106 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNotFound means that the source, or the ref or version requested
+	// from it, does not exist.
+	ErrNotFound = errors.New("source not found")
+
+	// ErrUnauthorized means that the source refused the credentials used
+	// to download it, or that none were given where some are required.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrUnsupportedScheme means that no getter is configured for the
+	// scheme or forced getter of the source.
+	ErrUnsupportedScheme = errors.New("download not supported for scheme")
+
+	// ErrSubdirNotFound means that the subdirectory of the source does not
+	// exist in the download.
+	ErrSubdirNotFound = errors.New("subdir not found")
+)
+
+// HTTPStatusError is returned when an HTTP server answers with an
+// unexpected status code. It matches ErrNotFound for 404 and 410 and
+// ErrUnauthorized for 401 and 403.
+type HTTPStatusError struct {
+	StatusCode int
+
+	// URL is the requested URL, with any credentials redacted.
+	URL string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("bad response code: %d", e.StatusCode)
+}
+
+func (e *HTTPStatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// sentinelError keeps the message and chain of err while also matching
+// sentinel with errors.Is.
+type sentinelError struct {
+	err      error
+	sentinel error
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() []error {
+	return []error{e.err, e.sentinel}
+}
+
+// withSentinel returns err marked with sentinel, or nil if err is nil.
+func withSentinel(err, sentinel error) error {
+	if err == nil {
+		return nil
+	}
+	return &sentinelError{err: err, sentinel: sentinel}
+}
+
+// fileError maps a filesystem error on a local source onto the sentinel
+// errors.
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return withSentinel(err, ErrNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		return withSentinel(err, ErrUnauthorized)
+	}
+	return err
+}
+
+// commandError maps err, returned by getRunCommand, onto the sentinel errors
+// if the command's output matches one of the given lowercased messages.
+func commandError(err error, notFound, unauthorized []string) error {
+	msg := strings.ToLower(err.Error())
+	for _, s := range notFound {
+		if strings.Contains(msg, s) {
+			return withSentinel(err, ErrNotFound)
+		}
+	}
+	for _, s := range unauthorized {
+		if strings.Contains(msg, s) {
+			return withSentinel(err, ErrUnauthorized)
+		}
+	}
+	return err
+}
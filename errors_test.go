@@ -0,0 +1,206 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+func TestHTTPStatusError(t *testing.T) {
+	cases := []struct {
+		Code         int
+		NotFound     bool
+		Unauthorized bool
+	}{
+		{http.StatusNotFound, true, false},
+		{http.StatusGone, true, false},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusForbidden, false, true},
+		{http.StatusInternalServerError, false, false},
+	}
+
+	for _, tc := range cases {
+		var err error = &HTTPStatusError{StatusCode: tc.Code}
+		if got := errors.Is(err, ErrNotFound); got != tc.NotFound {
+			t.Fatalf("%d: expected ErrNotFound %t, got %t", tc.Code, tc.NotFound, got)
+		}
+		if got := errors.Is(err, ErrUnauthorized); got != tc.Unauthorized {
+			t.Fatalf("%d: expected ErrUnauthorized %t, got %t", tc.Code, tc.Unauthorized, got)
+		}
+	}
+}
+
+func TestGet_errors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/private":
+			rw.WriteHeader(http.StatusForbidden)
+		case "/broken":
+			rw.WriteHeader(http.StatusBadGateway)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	t.Run("http not found", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "file")
+		u := strings.Replace(s.URL, "http://", "http://user:secret@", 1) + "/nope"
+		err := GetFile(dst, u)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected an HTTPStatusError, got %v", err)
+		}
+		if statusErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("bad status code: %d", statusErr.StatusCode)
+		}
+		if expected := strings.Replace(u, "secret", "redacted", 1); statusErr.URL != expected {
+			t.Fatalf("expected URL %q, got %q", expected, statusErr.URL)
+		}
+		if err.Error() != "bad response code: 404" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("http unauthorized", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "file")
+		if err := GetFile(dst, s.URL+"/private"); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected ErrUnauthorized, got %v", err)
+		}
+	})
+
+	t.Run("http retryable status", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "file")
+		err := GetFile(dst, s.URL+"/broken")
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+			t.Fatalf("expected an HTTPStatusError, got %v", err)
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "file")
+		err := GetFile(dst, testModule("nope"))
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected the native error to be kept, got %v", err)
+		}
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		err := Get(t.TempDir(), "nope://example.com/foo")
+		if !errors.Is(err, ErrUnsupportedScheme) {
+			t.Fatalf("expected ErrUnsupportedScheme, got %v", err)
+		}
+		if err.Error() != "download not supported for scheme 'nope'" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("subdir not found", func(t *testing.T) {
+		err := Get(filepath.Join(t.TempDir(), "dst"), testModule("basic")+"//nope")
+		if !errors.Is(err, ErrSubdirNotFound) {
+			t.Fatalf("expected ErrSubdirNotFound, got %v", err)
+		}
+	})
+}
+
+func TestCommandError(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Map    func(error) error
+		Output string
+		Target error
+	}{
+		{"git repository", gitError, "remote: Repository not found.\nfatal: repository 'https://example.com/foo.git/' not found", ErrNotFound},
+		{"git ref", gitError, "error: pathspec 'nope' did not match any file(s) known to git", ErrNotFound},
+		{"git auth", gitError, "fatal: Authentication failed for 'https://example.com/foo.git/'", ErrUnauthorized},
+		{"git ssh", gitError, "git@example.com: Permission denied (publickey).", ErrUnauthorized},
+		{"git network", gitError, "fatal: unable to access 'https://example.com/foo.git/': Could not resolve host: example.com", nil},
+		{"hg repository", hgError, "abort: repository /tmp/nope not found", ErrNotFound},
+		{"hg revision", hgError, "abort: unknown revision 'nope'", ErrNotFound},
+		{"hg auth", hgError, "abort: authorization failed", ErrUnauthorized},
+		{"hg no repository", hgError, "abort: there is no Mercurial repository here (.hg not found)", ErrNotFound},
+		{"hg missing file", hgError, "abort: nope.txt: no such file in rev 0123456789ab", nil},
+		{"hg command", hgError, "sh: 1: hg: not found", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			native := fmt.Errorf("/usr/bin/git exited with 128: %s", tc.Output)
+			err := tc.Map(native)
+			if err.Error() != native.Error() {
+				t.Fatalf("message changed: %s", err)
+			}
+			for _, sentinel := range []error{ErrNotFound, ErrUnauthorized} {
+				if got, expected := errors.Is(err, sentinel), sentinel == tc.Target; got != expected {
+					t.Fatalf("errors.Is(%v): expected %t, got %t", sentinel, expected, got)
+				}
+			}
+		})
+	}
+}
+
+// testS3APIError mimics the API errors returned by the AWS SDK.
+type testS3APIError struct{ code string }
+
+func (e *testS3APIError) Error() string     { return "api error " + e.code }
+func (e *testS3APIError) ErrorCode() string { return e.code }
+
+func TestS3Error(t *testing.T) {
+	cases := []struct {
+		Code   string
+		Target error
+	}{
+		{"NoSuchKey", ErrNotFound},
+		{"NoSuchBucket", ErrNotFound},
+		{"AccessDenied", ErrUnauthorized},
+		{"InvalidAccessKeyId", ErrUnauthorized},
+	}
+
+	for _, tc := range cases {
+		native := fmt.Errorf("operation error S3: GetObject: %w", &testS3APIError{tc.Code})
+		err := s3Error(native)
+		if !errors.Is(err, tc.Target) {
+			t.Fatalf("%s: expected %v, got %v", tc.Code, tc.Target, err)
+		}
+		var apiErr *testS3APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%s: expected the native error to be kept", tc.Code)
+		}
+	}
+}
+
+func TestGCSError(t *testing.T) {
+	cases := []struct {
+		Err    error
+		Target error
+	}{
+		{storage.ErrObjectNotExist, ErrNotFound},
+		{storage.ErrBucketNotExist, ErrNotFound},
+		{&googleapi.Error{Code: http.StatusForbidden}, ErrUnauthorized},
+		{&googleapi.Error{Code: http.StatusUnauthorized}, ErrUnauthorized},
+	}
+
+	for _, tc := range cases {
+		if err := gcsError(tc.Err); !errors.Is(err, tc.Target) {
+			t.Fatalf("%v: expected %v, got %v", tc.Err, tc.Target, err)
+		}
+	}
+}
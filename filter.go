@@ -0,0 +1,125 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects the files of a directory download by their path relative
+// to the root of what is written to the destination.
+//
+// Patterns are matched with path.Match against slash separated paths, and
+// a "**" element matches any number of directories. A pattern without a
+// slash matches the name of a file or of any directory it is in, at any
+// depth: "*.tf" selects Terraform files everywhere and "docs" every docs
+// directory. A pattern with a slash is matched from the root against the
+// path of a file or of any directory it is in, like "modules/*/main.tf" or
+// "test/". A pattern with a trailing slash only matches directories.
+//
+// A file is selected if it matches none of the Exclude patterns and, if
+// there are Include patterns, one of them.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// NewFilter returns the Filter of the given patterns. It returns an error
+// if a pattern is malformed, and nil if there are no patterns.
+func NewFilter(include, exclude []string) (*Filter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	for _, p := range append(append([]string(nil), include...), exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return &Filter{Include: include, Exclude: exclude}, nil
+}
+
+// with returns the Filter with the patterns of f and the given ones.
+func (f *Filter) with(include, exclude []string) (*Filter, error) {
+	if f != nil {
+		include = append(append([]string(nil), f.Include...), include...)
+		exclude = append(append([]string(nil), f.Exclude...), exclude...)
+	}
+	return NewFilter(include, exclude)
+}
+
+// Match reports whether the file at the slash separated path name is
+// selected. A nil Filter selects every file.
+func (f *Filter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	if matchAnyGlob(f.Exclude, name, false) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAnyGlob(f.Include, name, false)
+}
+
+// matchDir reports whether files in the directory at the slash separated
+// path name may be selected. Directories are only excluded as a whole,
+// since an Include pattern can match the files in any of them.
+func (f *Filter) matchDir(name string) bool {
+	return f == nil || !matchAnyGlob(f.Exclude, name, true)
+}
+
+// matchAnyGlob reports whether one of patterns matches name or one of the
+// directories it is in. dir reports whether name is a directory.
+func matchAnyGlob(patterns []string, name string, dir bool) bool {
+	elems := strings.Split(name, "/")
+	for _, p := range patterns {
+		if !strings.Contains(p, "/") {
+			for _, e := range elems {
+				if ok, _ := path.Match(p, e); ok {
+					return true
+				}
+			}
+			continue
+		}
+
+		// A directory pattern doesn't match the last element of a file.
+		n := len(elems)
+		if strings.HasSuffix(p, "/") {
+			p = strings.TrimSuffix(p, "/")
+			if !dir {
+				n--
+			}
+		}
+		pelems := strings.Split(strings.TrimPrefix(p, "/"), "/")
+		for i := 0; i < n; i++ {
+			if matchGlobElems(pelems, elems[:i+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlobElems matches the elements of a path against the elements of a
+// pattern, where "**" matches any number of elements.
+func matchGlobElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchGlobElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
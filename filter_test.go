@@ -0,0 +1,167 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testListFiles returns the slash separated paths of the files in dir.
+func testListFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestFilter_Match(t *testing.T) {
+	cases := []struct {
+		Include []string
+		Exclude []string
+		Name    string
+		Match   bool
+	}{
+		{nil, nil, "main.tf", true},
+		{[]string{"*.tf"}, nil, "main.tf", true},
+		{[]string{"*.tf"}, nil, "modules/foo/main.tf", true},
+		{[]string{"*.tf"}, nil, "README.md", false},
+		{[]string{"docs"}, nil, "docs/index.md", true},
+		{[]string{"docs"}, nil, "modules/docs/index.md", true},
+		{[]string{"modules/*/main.tf"}, nil, "modules/foo/main.tf", true},
+		{[]string{"modules/*/main.tf"}, nil, "main.tf", false},
+		{[]string{"modules/**/*.tf"}, nil, "modules/a/b/c.tf", true},
+		{[]string{"modules/**/*.tf"}, nil, "modules/c.tf", true},
+		{[]string{"modules/**/*.tf"}, nil, "other/c.tf", false},
+		{nil, []string{"test/"}, "test/foo_test.go", false},
+		{nil, []string{"test/"}, "pkg/test/foo_test.go", true},
+		{nil, []string{"test/"}, "test", true},
+		{nil, []string{".git"}, "sub/.git/config", false},
+		{[]string{"*.tf"}, []string{"examples"}, "examples/main.tf", false},
+	}
+
+	for _, tc := range cases {
+		f, err := NewFilter(tc.Include, tc.Exclude)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if got := f.Match(tc.Name); got != tc.Match {
+			t.Fatalf("include %q exclude %q on %q: expected %t", tc.Include, tc.Exclude, tc.Name, tc.Match)
+		}
+	}
+
+	if _, err := NewFilter([]string{"["}, nil); err == nil {
+		t.Fatal("expected an error for a malformed pattern")
+	}
+}
+
+func TestWithFilter_file(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	client := &Client{
+		Src:     testModule("basic"),
+		Dst:     dst,
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithFilter([]string{"*.tf"}, []string{"foo"})},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{"main.tf", "subdir/sub.tf"}
+	if files := testListFiles(t, dst); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("bad files: %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "foo")); !os.IsNotExist(err) {
+		t.Fatalf("excluded directory was created: %v", err)
+	}
+}
+
+func TestWithFilter_subdir(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	client := &Client{
+		Src:  testModule("decompress-zip/subdir.zip") + "//subdir?exclude=file1",
+		Dst:  dst,
+		Mode: ClientModeDir,
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The filter applies to the subdir
+	if files := testListFiles(t, dst); !reflect.DeepEqual(files, []string{"child"}) {
+		t.Fatalf("bad files: %v", files)
+	}
+}
+
+func TestWithFilter_archive(t *testing.T) {
+	cases := []struct {
+		Src   string
+		Files []string
+	}{
+		{"decompress-tgz/multiple_dir.tar.gz?include=test1", []string{"test1"}},
+		{"decompress-tgz/multiple_dir.tar.gz?exclude=dir", []string{"test1"}},
+		{"decompress-tgz/multiple_dir.tar.gz?include=dir%2F", []string{"dir/test2"}},
+		{"decompress-zip/subdir.zip?include=child", []string{"subdir/child"}},
+		{"decompress-zip/subdir.zip?exclude=subdir", []string{"file1"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Src, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "target")
+			client := &Client{
+				Src:  testModule(tc.Src),
+				Dst:  dst,
+				Mode: ClientModeDir,
+			}
+			if err := client.Get(); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if files := testListFiles(t, dst); !reflect.DeepEqual(files, tc.Files) {
+				t.Fatalf("bad files: %v", files)
+			}
+		})
+	}
+}
+
+func TestWithFilter_git(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "filter")
+	repo.commitFile("main.tf", "hello")
+	repo.commitFile("README.md", "world")
+
+	dst := filepath.Join(t.TempDir(), "target")
+	client := &Client{
+		Src:     "git::" + repo.url.String() + "?exclude=%2A.md",
+		Dst:     dst,
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithFilter(nil, []string{".git"})},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if files := testListFiles(t, dst); !reflect.DeepEqual(files, []string{"main.tf"}) {
+		t.Fatalf("bad files: %v", files)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// WritableFS is a filesystem that downloads can be written to. Paths are
+// native paths, like Client.Dst, and have the same meaning as for the
+// functions of the os package with the same names as the methods.
+//
+// Getters and decompressors only write the destination of a download
+// through it. Temporary files, like archives before they are unpacked,
+// are always stored on the OS filesystem.
+type WritableFS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (WritableFile, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Symlink(oldname, newname string) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+}
+
+// WritableFile is a file opened by a WritableFS.
+type WritableFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// OSFS is the WritableFS of the operating system. It is the default.
+type OSFS struct{}
+
+var _ LinkFS = OSFS{}
+
+func (OSFS) OpenFile(name string, flag int, perm os.FileMode) (WritableFile, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// Don't return a typed nil.
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (OSFS) Chmod(name string, mode os.FileMode) error { return os.Chmod(name, mode) }
+
+func (OSFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (OSFS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+
+func (OSFS) Link(oldname, newname string) error { return os.Link(oldname, newname) }
+
+func (OSFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func (OSFS) Remove(name string) error { return os.Remove(name) }
+
+func (OSFS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+func (OSFS) Lstat(name string) (os.FileInfo, error) { return os.Lstat(name) }
+
+func (OSFS) ReadDir(name string) ([]os.DirEntry, error) { return os.ReadDir(name) }
+
+// destFS returns fsys, or OSFS if it is nil.
+func destFS(fsys WritableFS) WritableFS {
+	if fsys == nil {
+		return OSFS{}
+	}
+	return fsys
+}
+
+// isOSFS reports whether fsys writes to the OS filesystem.
+func isOSFS(fsys WritableFS) bool {
+	switch fsys := fsys.(type) {
+	case nil, OSFS, *OSFS:
+		return true
+	case *limitFS:
+		return isOSFS(fsys.WritableFS)
+	}
+	return false
+}
+
+// mkdirTempFS is os.MkdirTemp for fsys.
+func mkdirTempFS(fsys WritableFS, dir, pattern string) (string, error) {
+	if isOSFS(fsys) {
+		return os.MkdirTemp(dir, pattern)
+	}
+
+	for range 10000 {
+		name := filepath.Join(dir, pattern+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		_, err := fsys.Lstat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, fsys.MkdirAll(name, 0700)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("failed to create a temporary directory in %s", dir)
+}
+
+// walkFS calls fn for path and, if it is a directory, every file below it,
+// in the order returned by ReadDir. Symbolic links are not followed. If fn
+// returns filepath.SkipDir for a directory, the files below it are skipped.
+func walkFS(fsys WritableFS, path string, fn func(path string, info os.FileInfo) error) error {
+	info, err := fsys.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(path, info); err != nil || !info.IsDir() {
+		if err == filepath.SkipDir && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := walkFS(fsys, filepath.Join(path, e.Name()), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
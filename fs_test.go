@@ -0,0 +1,479 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory WritableFS.
+type memFS struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	mode    os.FileMode
+	modTime time.Time
+	data    []byte
+	target  string
+}
+
+func newMemFS() *memFS {
+	return &memFS{nodes: map[string]*memNode{
+		string(filepath.Separator): {mode: os.ModeDir | 0755},
+	}}
+}
+
+func (m *memFS) node(op, name string) (string, *memNode, error) {
+	name = filepath.Clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return name, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return name, n, nil
+}
+
+func (m *memFS) parent(op, name string) error {
+	if _, n, err := m.node(op, filepath.Dir(name)); err != nil {
+		return err
+	} else if !n.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: errors.New("not a directory")}
+	}
+	return nil
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (WritableFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.node("open", name)
+	if err != nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, err
+		}
+		if err := m.parent("open", name); err != nil {
+			return nil, err
+		}
+		n = &memNode{mode: perm, modTime: time.Now()}
+		m.nodes[name] = n
+	}
+	if n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+	if flag&os.O_TRUNC != 0 {
+		n.data = nil
+	}
+	return &memFile{fs: m, name: name, node: n}, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path = filepath.Clean(path)
+	for p := path; ; p = filepath.Dir(p) {
+		if n, ok := m.nodes[p]; ok {
+			if !n.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: p, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		m.nodes[p] = &memNode{mode: os.ModeDir | perm, modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *memFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.node("chmod", name)
+	if err != nil {
+		return err
+	}
+	n.mode = n.mode&os.ModeType | mode.Perm()
+	return nil
+}
+
+func (m *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.node("chtimes", name)
+	if err != nil {
+		return err
+	}
+	n.modTime = mtime
+	return nil
+}
+
+func (m *memFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newname = filepath.Clean(newname)
+	if err := m.parent("symlink", newname); err != nil {
+		return err
+	}
+	if _, ok := m.nodes[newname]; ok {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	m.nodes[newname] = &memNode{mode: os.ModeSymlink | 0777, target: oldname}
+	return nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	if _, _, err := m.node("rename", oldpath); err != nil {
+		return err
+	}
+	if err := m.parent("rename", newpath); err != nil {
+		return err
+	}
+	for _, p := range m.paths(newpath) {
+		delete(m.nodes, p)
+	}
+	for _, p := range m.paths(oldpath) {
+		m.nodes[newpath+p[len(oldpath):]] = m.nodes[p]
+		delete(m.nodes, p)
+	}
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, _, err := m.node("remove", name)
+	if err != nil {
+		return err
+	}
+	if len(m.paths(name)) > 1 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+func (m *memFS) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.paths(filepath.Clean(path)) {
+		delete(m.nodes, p)
+	}
+	return nil
+}
+
+func (m *memFS) Lstat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.node("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFileInfo{name: filepath.Base(name), node: n, size: int64(len(n.data))}, nil
+}
+
+func (m *memFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, _, err := m.node("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	var entries []os.DirEntry
+	for p, n := range m.nodes {
+		if p != name && filepath.Dir(p) == name {
+			fi := &memFileInfo{name: filepath.Base(p), node: n, size: int64(len(n.data))}
+			entries = append(entries, fs.FileInfoToDirEntry(fi))
+		}
+	}
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// paths returns path and every path below it.
+func (m *memFS) paths(path string) []string {
+	var paths []string
+	for p := range m.nodes {
+		if p == path || strings.HasPrefix(p, path+string(filepath.Separator)) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// files returns the content of every regular file, keyed by its path
+// relative to root with forward slashes.
+func (m *memFS) files(root string) map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files := map[string]string{}
+	for _, p := range m.paths(filepath.Clean(root)) {
+		if n := m.nodes[p]; n.mode.IsRegular() {
+			rel, _ := filepath.Rel(root, p)
+			files[filepath.ToSlash(rel)] = string(n.data)
+		}
+	}
+	return files
+}
+
+type memFile struct {
+	fs     *memFS
+	name   string
+	node   *memNode
+	offset int64
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if end := f.offset + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	n := copy(f.node.data[f.offset:], p)
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+		f.offset = offset
+	case io.SeekCurrent:
+		f.offset += offset
+	case io.SeekEnd:
+		f.offset = int64(len(f.node.data)) + offset
+	}
+	return f.offset, nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	return f.fs.Lstat(f.name)
+}
+
+func (f *memFile) Close() error { return nil }
+
+type memFileInfo struct {
+	name string
+	node *memNode
+	size int64
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return fi.node.mode }
+func (fi *memFileInfo) ModTime() time.Time { return fi.node.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.node.mode.IsDir() }
+func (fi *memFileInfo) Sys() any           { return nil }
+
+// testDestinationFS downloads src into dst on a new memFS and checks that
+// nothing was written to dst on the OS filesystem.
+func testDestinationFS(t *testing.T, src, dst string, opts ...ClientOption) (*memFS, *GetResult) {
+	t.Helper()
+
+	fsys := newMemFS()
+	client := &Client{
+		Src:     src,
+		Dst:     dst,
+		Mode:    ClientModeAny,
+		Options: append(opts, WithDestinationFS(fsys)),
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Fatalf("nothing should be written to the OS filesystem, got %v", err)
+	}
+	return fsys, result
+}
+
+func TestWithDestinationFS_dir(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, result := testDestinationFS(t, testModule("basic"), dst)
+
+	files := fsys.files(dst)
+	for _, name := range []string{"main.tf", "foo/main.tf", "subdir/sub.tf"} {
+		expected, err := os.ReadFile(filepath.Join(fixtureDir, "basic", name))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if files[name] != string(expected) {
+			t.Fatalf("bad %s: %q", name, files[name])
+		}
+	}
+	if result.Files != len(files) {
+		t.Fatalf("bad file count: %d", result.Files)
+	}
+}
+
+func TestWithDestinationFS_subdir(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, _ := testDestinationFS(t, testModule("basic//subdir"), dst)
+
+	files := fsys.files(dst)
+	if len(files) != 1 || files["sub.tf"] == "" {
+		t.Fatalf("bad files: %v", files)
+	}
+}
+
+func TestWithDestinationFS_archive(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, result := testDestinationFS(t, testModule("decompress-tgz/multiple_dir.tar.gz"), dst)
+
+	files := fsys.files(dst)
+	if files["test1"] != "Hello\n" || files["dir/test2"] != "Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+	if result.Decompressor != "tar.gz" {
+		t.Fatalf("bad decompressor: %q", result.Decompressor)
+	}
+}
+
+func TestWithDestinationFS_fileChecksum(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	src := testModule("basic-file/foo.txt?checksum=sha256:66a045b452102c59d840ec097d59d9467e13a3f34f6494e539ffd32c1bb35f18")
+	fsys, result := testDestinationFS(t, src, dst)
+
+	if files := fsys.files(dst); files["foo.txt"] != "Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+	if !result.ChecksumVerified {
+		t.Fatal("checksum should be verified")
+	}
+}
+
+func TestWithDestinationFS_http(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, _ := testDestinationFS(t, fmt.Sprintf("http://%s/file", ln.Addr()), dst)
+
+	if files := fsys.files(dst); files["file"] != "Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+}
+
+func TestWithDestinationFS_git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "fs")
+	repo.commitFile("main.tf", "# Hello\n")
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, _ := testDestinationFS(t, "git::"+repo.url.String(), dst)
+
+	if files := fsys.files(dst); files["main.tf"] != "# Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+}
+
+func TestWithDestinationFS_atomic(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, _ := testDestinationFS(t, testModule("basic"), dst, WithAtomic())
+
+	if files := fsys.files(dst); files["main.tf"] == "" {
+		t.Fatalf("bad files: %v", files)
+	}
+	entries, err := fsys.ReadDir(filepath.Dir(dst))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("staging directory should be removed, got %d entries", len(entries))
+	}
+}
+
+func TestWithDestinationFS_legacyGetter(t *testing.T) {
+	getters := map[string]Getter{
+		"mock": &MockGetter{Proxy: &FileGetter{Copy: true}},
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, _ := testDestinationFS(t, "mock::"+testModule("basic")+"/", dst,
+		WithGetters(getters), WithFilter(nil, []string{"foo"}))
+	if files := fsys.files(dst); len(files) != 2 || files["main.tf"] == "" || files["subdir/sub.tf"] == "" {
+		t.Fatalf("bad files: %v", files)
+	}
+
+	dst = filepath.Join(t.TempDir(), "dst")
+	fsys, _ = testDestinationFS(t, "mock::"+testModule("basic-file/foo.txt"), dst, WithGetters(getters))
+	if files := fsys.files(dst); files["foo.txt"] != "Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+}
+
+// legacyDecompressor hides all but the Decompress method of d.
+type legacyDecompressor struct {
+	d Decompressor
+}
+
+func (d legacyDecompressor) Decompress(dst, src string, dir bool, umask os.FileMode) error {
+	return d.d.Decompress(dst, src, dir, umask)
+}
+
+func TestWithDestinationFS_legacyDecompressor(t *testing.T) {
+	decompressors := map[string]Decompressor{
+		"tar.gz": legacyDecompressor{new(TarGzipDecompressor)},
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	fsys, result := testDestinationFS(t, testModule("decompress-tgz/multiple_dir.tar.gz"), dst,
+		WithDecompressors(decompressors), WithFilter(nil, []string{"dir"}))
+	if files := fsys.files(dst); len(files) != 1 || files["test1"] != "Hello\n" {
+		t.Fatalf("bad files: %v", files)
+	}
+	if result.Files != 1 {
+		t.Fatalf("bad file count: %d", result.Files)
+	}
+
+	client := &Client{
+		Src:     testModule("decompress-tgz/multiple_dir.tar.gz?archive_strip=1"),
+		Dst:     filepath.Join(t.TempDir(), "dst"),
+		Mode:    ClientModeAny,
+		Options: []ClientOption{WithDecompressors(decompressors), WithDestinationFS(newMemFS())},
+	}
+	if err := client.Get(); err == nil || !strings.Contains(err.Error(), "archive_strip") {
+		t.Fatalf("expected an archive_strip error, got %v", err)
+	}
+}
@@ -16,9 +16,14 @@ package getter
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"syscall"
 
@@ -51,6 +56,117 @@ type Getter interface {
 	SetClient(*Client)
 }
 
+// GetterRequest is a single call to a GetterContext.
+type GetterRequest struct {
+	// Client is the client the download runs for. It may be nil when a
+	// getter is used directly.
+	Client *Client
+
+	// Dst is the path to download to. It is empty for ClientModeContext.
+	Dst string
+
+	// URL is the source to download.
+	URL *url.URL
+
+	// FS is the filesystem to write Dst to. If it is nil, Dst is on the
+	// OS filesystem.
+	FS WritableFS
+
+	// Filter selects the files of a directory download, relative to Dst.
+	// If it is nil, every file is downloaded.
+	Filter *Filter
+}
+
+// GetterContext is implemented by getters whose methods take the context
+// to use and the details of the call, rather than depending on the state
+// set by SetClient. The client prefers it over the methods of Getter.
+type GetterContext interface {
+	// GetContext, GetFileContext and ClientModeContext are like Get,
+	// GetFile and ClientMode, but must stop as soon as ctx is done.
+	GetContext(ctx context.Context, req *GetterRequest) error
+	GetFileContext(ctx context.Context, req *GetterRequest) error
+	ClientModeContext(ctx context.Context, req *GetterRequest) (ClientMode, error)
+}
+
+// FilenameReporter is implemented by getters that can tell the name the
+// server suggests for a file before downloading it. In ClientModeAny, the
+// client names files after it, and picks their decompressor from it. The
+// name is only a hint: if it can't be told, the file is named after the
+// URL and errors don't fail the download.
+type FilenameReporter interface {
+	// FilenameContext returns the base name the server suggests for the
+	// file at req.URL, or "" if it suggests none. Dst is empty.
+	FilenameContext(ctx context.Context, req *GetterRequest) (string, error)
+}
+
+// getterContext returns g as a GetterContext, adapting it if it only
+// implements Getter. The adapted getter does not see ctx.
+func getterContext(g Getter) GetterContext {
+	if gc, ok := g.(GetterContext); ok {
+		return gc
+	}
+	return &legacyGetter{g}
+}
+
+// legacyGetter adapts a Getter to GetterContext. A Getter only writes to
+// the OS filesystem and knows nothing of filters, so downloads to another
+// filesystem or with a filter go through a temporary directory.
+type legacyGetter struct {
+	Getter
+}
+
+func (g *legacyGetter) GetContext(ctx context.Context, req *GetterRequest) error {
+	if !isOSFS(req.FS) || req.Filter != nil {
+		return getThroughOS(ctx, req, g.GetContext)
+	}
+	return g.Get(req.Dst, req.URL)
+}
+
+func (g *legacyGetter) GetFileContext(ctx context.Context, req *GetterRequest) error {
+	if !isOSFS(req.FS) {
+		return getFileThroughOS(ctx, req, g.GetFileContext)
+	}
+	return g.GetFile(req.Dst, req.URL)
+}
+
+func (g *legacyGetter) ClientModeContext(_ context.Context, req *GetterRequest) (ClientMode, error) {
+	return g.ClientMode(req.URL)
+}
+
+// CloneableGetter is implemented by getters that can be copied, so that
+// each client configures its own instance instead of sharing the one in
+// its Getters map. Getters that don't implement it are shared by every
+// client using them, and SetClient is called on them by each.
+type CloneableGetter interface {
+	Getter
+
+	// Clone returns a copy of the getter with the same settings, that
+	// is not yet set up for any client.
+	Clone() Getter
+}
+
+// cloneGetters returns a copy of getters in which every CloneableGetter
+// is replaced by a clone. A getter registered under several keys, like
+// http and https, is cloned once and stays shared between those keys.
+func cloneGetters(getters map[string]Getter) map[string]Getter {
+	clones := make(map[Getter]Getter, len(getters))
+	result := make(map[string]Getter, len(getters))
+	for k, g := range getters {
+		cg, ok := g.(CloneableGetter)
+		if !ok {
+			result[k] = g
+			continue
+		}
+		clone, ok := clones[g]
+		if !ok {
+			clone = cg.Clone()
+			clones[g] = clone
+		}
+		result[k] = clone
+	}
+	return result
+}
+
 // Getters is the mapping of scheme to the Getter implementation that will
 // be used to get a dependency.
 var Getters map[string]Getter
@@ -62,6 +178,14 @@ var forcedRegexp = regexp.MustCompile(`^([A-Za-z0-9]+)::(.+)$`)
 // httpClient is the default client to be used by HttpGetters.
 var httpClient = cleanhttp.DefaultClient()
 
+// insecureHttpClient is the default client to be used by HttpGetters of
+// clients that skip TLS certificate verification.
+var insecureHttpClient = func() *http.Client {
+	transport := cleanhttp.DefaultTransport()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}()
+
 func init() {
 	httpGetter := &HttpGetter{
 		Netrc: true,
@@ -118,6 +242,68 @@ func GetFile(dst, src string, opts ...ClientOption) error {
 	}).Get()
 }
 
+// getThroughOS is used by getters that run tools which can only write to
+// the OS filesystem, when req.FS is another filesystem or req.Filter is
+// set. It runs get to download into a temporary directory and copies the
+// selected files to req.Dst.
+func getThroughOS(ctx context.Context, req *GetterRequest, get func(context.Context, *GetterRequest) error) error {
+	td, tdcloser, err := mkdirTemp("", "getter")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tdcloser.Close() }()
+
+	// The download directory must not exist yet. It is bounded by the
+	// limits of the client like the destination is.
+	tmpDst := filepath.Join(td, "dst")
+	err = req.Client.watchLimits(ctx, tmpDst, func(ctx context.Context) error {
+		return get(ctx, &GetterRequest{Client: req.Client, Dst: tmpDst, URL: req.URL})
+	})
+	if err != nil {
+		return err
+	}
+
+	fsys, c := destFS(req.FS), req.Client
+	if err := fsys.RemoveAll(req.Dst); err != nil {
+		return err
+	}
+	if err := fsys.MkdirAll(req.Dst, c.mode(0755)); err != nil {
+		return err
+	}
+	disableSymlinks := c != nil && c.DisableSymlinks
+	return copyDir(ctx, fsys, req.Dst, tmpDst, false, disableSymlinks, c.umask(), req.Filter)
+}
+
+// getFileThroughOS is like getThroughOS for downloads of a single file. It
+// runs get to download into a temporary file and copies it to req.Dst.
+func getFileThroughOS(ctx context.Context, req *GetterRequest, get func(context.Context, *GetterRequest) error) error {
+	td, tdcloser, err := mkdirTemp("", "getter")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tdcloser.Close() }()
+
+	tmpDst := filepath.Join(td, "file")
+	err = req.Client.watchLimits(ctx, tmpDst, func(ctx context.Context) error {
+		return get(ctx, &GetterRequest{Client: req.Client, Dst: tmpDst, URL: req.URL})
+	})
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(tmpDst)
+	if err != nil {
+		return err
+	}
+	fsys, c := destFS(req.FS), req.Client
+	if err := fsys.MkdirAll(filepath.Dir(req.Dst), c.mode(0755)); err != nil {
+		return err
+	}
+	disableSymlinks := c != nil && c.DisableSymlinks
+	_, err = copyFile(ctx, fsys, req.Dst, tmpDst, disableSymlinks, fi.Mode().Perm(), c.umask())
+	return err
+}
+
 // getRunCommand is a helper that will run a command and capture the output
 // in the case an error happens.
 func getRunCommand(cmd *exec.Cmd) error {
@@ -128,6 +314,23 @@ func getRunCommand(cmd *exec.Cmd) error {
 	if err == nil {
 		return nil
 	}
+	return commandRunError(cmd, err, buf.String())
+}
+
+// getCommandOutput runs cmd like getRunCommand, but returns its standard
+// output. Only the error output is included in errors.
+func getCommandOutput(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", commandRunError(cmd, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// commandRunError describes the failure err of cmd, which printed output.
+func commandRunError(cmd *exec.Cmd, err error, output string) error {
 	if exiterr, ok := err.(*exec.ExitError); ok {
 		// The program has exited with an exit code != 0
 		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
@@ -135,11 +338,11 @@ func getRunCommand(cmd *exec.Cmd) error {
 				"%s exited with %d: %s",
 				cmd.Path,
 				status.ExitStatus(),
-				buf.String())
+				output)
 		}
 	}
 
-	return fmt.Errorf("error running %s: %s", cmd.Path, buf.String())
+	return fmt.Errorf("error running %s: %s", cmd.Path, output)
 }
 
 // getForcedGetter takes a source and returns the tuple of the forced
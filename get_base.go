@@ -19,5 +19,24 @@ func (g *getter) Context() context.Context {
 	if g == nil || g.client == nil {
 		return context.Background()
 	}
-	return g.client.Ctx
+	return g.client.requestContext()
+}
+
+// setVersion records the immutable version a getter resolved the source
+// to on the in-progress GetResult of the client, if any.
+func (c *Client) setVersion(version string) {
+	if c == nil || c.result == nil {
+		return
+	}
+	c.result.Version = version
+}
+
+// setContent records the media type and the file name the server reported
+// for a file download on the in-progress GetResult of the client, if any.
+func (c *Client) setContent(contentType, filename string) {
+	if c == nil || c.result == nil {
+		return
+	}
+	c.result.ContentType = contentType
+	c.result.Filename = filename
 }
@@ -4,8 +4,16 @@
 package getter
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // FileGetter is a Getter implementation that will download a module from
@@ -19,7 +27,21 @@ type FileGetter struct {
 	Copy bool
 }
 
+// Clone implements CloneableGetter.
+func (g *FileGetter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	return &clone
+}
+
 func (g *FileGetter) ClientMode(u *url.URL) (ClientMode, error) {
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *FileGetter) ClientModeContext(ctx context.Context, gr *GetterRequest) (ClientMode, error) {
+	u := gr.URL
+
 	path := u.Path
 	if u.RawPath != "" {
 		path = u.RawPath
@@ -27,7 +49,7 @@ func (g *FileGetter) ClientMode(u *url.URL) (ClientMode, error) {
 
 	fi, err := os.Stat(path)
 	if err != nil {
-		return 0, err
+		return 0, fileError(err)
 	}
 
 	// Check if the source is a directory.
@@ -37,3 +59,131 @@ func (g *FileGetter) ClientMode(u *url.URL) (ClientMode, error) {
 
 	return ClientModeFile, nil
 }
+
+// OpenContext implements Opener.
+func (g *FileGetter) OpenContext(ctx context.Context, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	u := gr.URL
+
+	path := u.Path
+	if u.RawPath != "" {
+		path = u.RawPath
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, Metadata{}, fileError(fmt.Errorf("source path error: %w", err))
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, Metadata{}, err
+	}
+	if fi.IsDir() {
+		_ = f.Close()
+		return nil, Metadata{}, fmt.Errorf("source path must be a file")
+	}
+
+	md := Metadata{
+		Size:        fi.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(path)),
+	}
+	return f, md, nil
+}
+
+// CheckContext implements Checker. The modification times of the source
+// are compared first, and its content is only hashed if they changed.
+func (g *FileGetter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	u := gr.URL
+
+	path := u.Path
+	if u.RawPath != "" {
+		path = u.RawPath
+	}
+
+	// The source is walked, not the link to it.
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fileError(fmt.Errorf("source path error: %w", err))
+	}
+
+	meta, err := fileState(ctx, path, false)
+	if err != nil {
+		return nil, err
+	}
+	prevMeta, prevContent, _ := strings.Cut(previous, ":")
+	if meta == prevMeta {
+		return &CheckResult{State: previous}, nil
+	}
+
+	content, err := fileState(ctx, path, true)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckResult{
+		Changed: content != prevContent,
+		State:   meta + ":" + content,
+	}, nil
+}
+
+// fileState hashes the names and modes of the files at path along with
+// either their sizes and modification times, or their content.
+func fileState(ctx context.Context, path string, content bool) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00", filepath.ToSlash(rel), fi.Mode())
+
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "%s\x00", target)
+		case !fi.Mode().IsRegular():
+		case !content:
+			_, _ = fmt.Fprintf(h, "%d\x00%d\x00", fi.Size(), fi.ModTime().UnixNano())
+		default:
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			_ = f.Close()
+			if err != nil {
+				return err
+			}
+			_, _ = h.Write([]byte{0})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyDir copies the files of the directory at path selected by filter into
+// dst on fsys, replacing what dst held, for filesystems that can't link to
+// the source and for filtered downloads. c is the client of the download.
+func (g *FileGetter) copyDir(ctx context.Context, c *Client, fsys WritableFS, dst, path string, filter *Filter) error {
+	if err := fsys.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := fsys.MkdirAll(dst, c.mode(0755)); err != nil {
+		return err
+	}
+
+	disableSymlinks := c != nil && c.DisableSymlinks
+	return copyDir(ctx, fsys, dst, path, false, disableSymlinks, c.umask(), filter)
+}
@@ -15,8 +15,11 @@ type readerFunc func(p []byte) (n int, err error)
 
 func (rf readerFunc) Read(p []byte) (n int, err error) { return rf(p) }
 
-// Copy is a io.Copy cancellable by context
+// Copy is a io.Copy cancellable by context. It is also limited by the
+// RateLimiter of the client the context belongs to, if any.
 func Copy(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	limiter := rateLimiterFromContext(ctx)
+
 	// Copy will call the Reader and Writer interface multiple time, in order
 	// to copy by chunk (avoiding loading the whole file in memory).
 	return io.Copy(dst, readerFunc(func(p []byte) (int, error) {
@@ -28,14 +31,18 @@ func Copy(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
 			return 0, ctx.Err()
 		default:
 			// otherwise just run default io.Reader implementation
+			if limiter != nil {
+				return readRateLimited(ctx, limiter, src, p)
+			}
 			return src.Read(p)
 		}
 	}))
 }
 
-// copyReader copies from an io.Reader into a file, using umask to create the dst file
-func copyReader(dst string, src io.Reader, fmode, umask os.FileMode, fileSizeLimit int64) error {
-	dstF, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode(fmode, umask))
+// copyReader copies from an io.Reader into a file of fsys, using umask to create the dst file
+func copyReader(ctx context.Context, fsys WritableFS, dst string, src io.Reader, fmode, umask os.FileMode, fileSizeLimit int64) error {
+	fsys = destFS(fsys)
+	dstF, err := fsys.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode(fmode, umask))
 	if err != nil {
 		return err
 	}
@@ -45,22 +52,23 @@ func copyReader(dst string, src io.Reader, fmode, umask os.FileMode, fileSizeLim
 		src = io.LimitReader(src, fileSizeLimit)
 	}
 
-	_, err = io.Copy(dstF, src)
+	_, err = Copy(ctx, dstF, src)
 	if err != nil {
 		// Close & remove the file in case of partial write
 		_ = dstF.Close()
-		_ = os.Remove(dst)
+		_ = fsys.Remove(dst)
 		return err
 	}
 
 	// Explicitly chmod; the process umask is unconditionally applied otherwise.
 	// We'll mask the mode with our own umask, but that may be different than
 	// the process umask
-	return os.Chmod(dst, mode(fmode, umask))
+	return fsys.Chmod(dst, mode(fmode, umask))
 }
 
-// copyFile copies a file in chunks from src path to dst path, using umask to create the dst file
-func copyFile(ctx context.Context, dst, src string, disableSymlinks bool, fmode, umask os.FileMode) (int64, error) {
+// copyFile copies a file in chunks from src path to dst path of fsys, using umask to create the dst file
+func copyFile(ctx context.Context, fsys WritableFS, dst, src string, disableSymlinks bool, fmode, umask os.FileMode) (int64, error) {
+	fsys = destFS(fsys)
 	if disableSymlinks {
 		fileInfo, err := os.Lstat(src)
 		if err != nil {
@@ -77,7 +85,7 @@ func copyFile(ctx context.Context, dst, src string, disableSymlinks bool, fmode,
 	}
 	defer func() { _ = srcF.Close() }()
 
-	dstF, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode(fmode, umask))
+	dstF, err := fsys.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode(fmode, umask))
 	if err != nil {
 		return 0, err
 	}
@@ -87,13 +95,13 @@ func copyFile(ctx context.Context, dst, src string, disableSymlinks bool, fmode,
 	if err != nil {
 		// Close & remove the file in case of partial write
 		_ = dstF.Close()
-		_ = os.Remove(dst)
+		_ = fsys.Remove(dst)
 		return 0, err
 	}
 
 	// Explicitly chmod; the process umask is unconditionally applied otherwise.
 	// We'll mask the mode with our own umask, but that may be different than
 	// the process umask
-	err = os.Chmod(dst, mode(fmode, umask))
+	err = fsys.Chmod(dst, mode(fmode, umask))
 	return count, err
 }
@@ -6,7 +6,10 @@
 package getter
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
 	"os/exec"
@@ -16,7 +19,13 @@ import (
 )
 
 func (g *FileGetter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *FileGetter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+
 	path := u.Path
 	if u.RawPath != "" {
 		path = u.RawPath
@@ -24,11 +33,17 @@ func (g *FileGetter) Get(dst string, u *url.URL) error {
 
 	// The source path must exist and be a directory to be usable.
 	if fi, err := os.Stat(path); err != nil {
-		return fmt.Errorf("source path error: %w", err)
+		return fileError(fmt.Errorf("source path error: %w", err))
 	} else if !fi.IsDir() {
 		return fmt.Errorf("source path must be a directory")
 	}
 
+	// The source can only be linked to from the OS filesystem, and only as
+	// a whole.
+	if !isOSFS(gr.FS) || gr.Filter != nil {
+		return g.copyDir(ctx, gr.Client, gr.FS, dst, path, gr.Filter)
+	}
+
 	fi, err := os.Lstat(dst)
 	if err != nil && !os.IsNotExist(err) {
 		return err
@@ -48,7 +63,7 @@ func (g *FileGetter) Get(dst string, u *url.URL) error {
 	}
 
 	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
+	if err := os.MkdirAll(filepath.Dir(dst), gr.Client.mode(0755)); err != nil {
 		return err
 	}
 
@@ -64,7 +79,14 @@ func (g *FileGetter) Get(dst string, u *url.URL) error {
 }
 
 func (g *FileGetter) GetFile(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetFileContext implements GetterContext.
+func (g *FileGetter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
+
 	path := u.Path
 	if u.RawPath != "" {
 		path = u.RawPath
@@ -72,31 +94,32 @@ func (g *FileGetter) GetFile(dst string, u *url.URL) error {
 
 	// The source path must exist and be a directory to be usable.
 	if fi, err := os.Stat(path); err != nil {
-		return fmt.Errorf("source path error: %w", err)
+		return fileError(fmt.Errorf("source path error: %w", err))
 	} else if fi.IsDir() {
 		return fmt.Errorf("source path must be a file")
 	}
 
-	_, err := os.Lstat(dst)
-	if err != nil && !os.IsNotExist(err) {
+	_, err := fsys.Lstat(dst)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
 
 	// If the destination already exists, it must be a symlink
 	if err == nil {
 		// Remove the destination
-		if err := os.Remove(dst); err != nil {
+		if err := fsys.Remove(dst); err != nil {
 			return err
 		}
 	}
 
 	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), gr.Client.mode(0755)); err != nil {
 		return err
 	}
 
-	// If we're not copying, just symlink and we're done
-	if !g.Copy {
+	// If we're not copying, just symlink and we're done. The source can
+	// only be linked to from the OS filesystem.
+	if !g.Copy && isOSFS(fsys) {
 		if err = os.Symlink(path, dst); err == nil {
 			return err
 		}
@@ -116,12 +139,12 @@ func (g *FileGetter) GetFile(dst string, u *url.URL) error {
 
 	var disableSymlinks bool
 
-	if g.client != nil && g.client.DisableSymlinks {
+	if gr.Client != nil && gr.Client.DisableSymlinks {
 		disableSymlinks = true
 	}
 
 	// Copy
-	_, err = copyFile(ctx, dst, path, disableSymlinks, 0666, g.client.umask())
+	_, err = copyFile(ctx, fsys, dst, path, disableSymlinks, 0666, gr.Client.umask())
 	return err
 }
 
@@ -5,7 +5,11 @@ package getter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -14,6 +18,7 @@ import (
 	"time"
 
 	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 
 	"cloud.google.com/go/storage"
@@ -29,15 +34,35 @@ type GCSGetter struct {
 	// complete within. Zero value means no timeout.
 	Timeout time.Duration
 
-	// FileSizeLimit limits the size of an single
-	// decompressed file.
+	// FileSizeLimit limits the size of a single object. Larger objects
+	// fail to download.
 	//
 	// The zero value means no limit.
 	FileSizeLimit int64
 }
 
+// Clone implements CloneableGetter.
+func (g *GCSGetter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	return &clone
+}
+
+// Pin implements Pinner. The generation is selected with the fragment.
+func (g *GCSGetter) Pin(u *url.URL, version string) *url.URL {
+	pinned := *u
+	pinned.Fragment = version
+	pinned.RawFragment = ""
+	return &pinned
+}
+
 func (g *GCSGetter) ClientMode(u *url.URL) (ClientMode, error) {
-	ctx := g.Context()
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *GCSGetter) ClientModeContext(ctx context.Context, gr *GetterRequest) (ClientMode, error) {
+	u := gr.URL
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -59,7 +84,7 @@ func (g *GCSGetter) ClientMode(u *url.URL) (ClientMode, error) {
 	for {
 		obj, err := iter.Next()
 		if err != nil && err != iterator.Done {
-			return 0, err
+			return 0, gcsError(err)
 		}
 
 		if err == iterator.Done {
@@ -80,7 +105,13 @@ func (g *GCSGetter) ClientMode(u *url.URL) (ClientMode, error) {
 }
 
 func (g *GCSGetter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *GCSGetter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -95,19 +126,19 @@ func (g *GCSGetter) Get(dst string, u *url.URL) error {
 	}
 
 	// Remove destination if it already exists
-	_, err = os.Stat(dst)
-	if err != nil && !os.IsNotExist(err) {
+	_, err = fsys.Lstat(dst)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
 	if err == nil {
 		// Remove the destination
-		if err := os.RemoveAll(dst); err != nil {
+		if err := fsys.RemoveAll(dst); err != nil {
 			return err
 		}
 	}
 
 	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), gr.Client.mode(0755)); err != nil {
 		return err
 	}
 
@@ -121,7 +152,7 @@ func (g *GCSGetter) Get(dst string, u *url.URL) error {
 	for {
 		obj, err := iter.Next()
 		if err != nil && err != iterator.Done {
-			return err
+			return gcsError(err)
 		}
 		if err == iterator.Done {
 			break
@@ -133,9 +164,12 @@ func (g *GCSGetter) Get(dst string, u *url.URL) error {
 			if err != nil {
 				return err
 			}
+			if !gr.Filter.Match(filepath.ToSlash(objDst)) {
+				continue
+			}
 			objDst = filepath.Join(dst, objDst)
 			// Download the matching object.
-			err = g.getObject(ctx, client, objDst, bucket, obj.Name, "")
+			_, err = g.getObject(ctx, gr.Client, client, fsys, objDst, bucket, obj.Name, "")
 			if err != nil {
 				return err
 			}
@@ -145,7 +179,13 @@ func (g *GCSGetter) Get(dst string, u *url.URL) error {
 }
 
 func (g *GCSGetter) GetFile(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetFileContext implements GetterContext.
+func (g *GCSGetter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -163,34 +203,177 @@ func (g *GCSGetter) GetFile(dst string, u *url.URL) error {
 	if err != nil {
 		return err
 	}
-	return g.getObject(ctx, client, dst, bucket, object, fragment)
+	generation, err := g.getObject(ctx, gr.Client, client, fsys, dst, bucket, object, fragment)
+	if err != nil {
+		return err
+	}
+	gr.Client.setVersion(strconv.FormatInt(generation, 10))
+	return nil
 }
 
-func (g *GCSGetter) getObject(ctx context.Context, client *storage.Client, dst, bucket, object, fragment string) error {
+// OpenContext implements Opener.
+func (g *GCSGetter) OpenContext(ctx context.Context, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	// The timeout covers reading the object, so it is only cancelled once
+	// the object is closed.
+	var cancel context.CancelFunc
+	if g.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	bucket, object, fragment, err := g.parseURL(gr.URL)
+	if err != nil {
+		cancel()
+		return nil, Metadata{}, err
+	}
+
+	client, err := g.getClient(ctx)
+	if err != nil {
+		cancel()
+		return nil, Metadata{}, err
+	}
+
+	rc, err := g.newReader(ctx, client, bucket, object, fragment)
+	if err != nil {
+		cancel()
+		return nil, Metadata{}, err
+	}
+
+	md := Metadata{
+		Size:        rc.Attrs.Size,
+		ContentType: rc.Attrs.ContentType,
+	}
+	gr.Client.setVersion(strconv.FormatInt(rc.Attrs.Generation, 10))
+	return &limitedWrappedReaderCloser{
+		underlying: rc,
+		closeFn: func() error {
+			defer cancel()
+			return rc.Close()
+		},
+	}, md, nil
+}
+
+// getObject downloads a single object to dst on fsys for the client c and
+// returns the generation that was read.
+func (g *GCSGetter) getObject(ctx context.Context, c *Client, client *storage.Client, fsys WritableFS, dst, bucket, object, fragment string) (int64, error) {
+	rc, err := g.newReader(ctx, client, bucket, object, fragment)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Create all the parent directories
+	if err := fsys.MkdirAll(filepath.Dir(dst), c.mode(0755)); err != nil {
+		return 0, err
+	}
+
+	if g.FileSizeLimit > 0 && rc.Attrs.Size > g.FileSizeLimit {
+		return 0, fmt.Errorf("object %s is larger than the limit of %d bytes", object, g.FileSizeLimit)
+	}
+
+	// The size of objects is also bounded by the Limits of the client,
+	// which fsys enforces.
+	if err := copyReader(ctx, fsys, dst, rc, 0666, c.umask(), 0); err != nil {
+		return 0, gcsError(err)
+	}
+	return rc.Attrs.Generation, nil
+}
+
+// newReader opens a single object, at the generation in fragment if it is
+// not empty.
+func (g *GCSGetter) newReader(ctx context.Context, client *storage.Client, bucket, object, fragment string) (*storage.Reader, error) {
 	var rc *storage.Reader
 	var err error
 	if fragment != "" {
 		var generation int64
 		generation, err = strconv.ParseInt(fragment, 10, 64)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		rc, err = client.Bucket(bucket).Object(object).Generation(generation).NewReader(ctx)
 	} else {
 		rc, err = client.Bucket(bucket).Object(object).NewReader(ctx)
 	}
 	if err != nil {
-		return err
+		return nil, gcsError(err)
 	}
-	defer func() { _ = rc.Close() }()
+	return rc, nil
+}
 
-	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
-		return err
+// CheckContext implements Checker. The state of an object is its
+// generation. If there is no object at the path, it is the one of every
+// object below it.
+func (g *GCSGetter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	bucket, object, fragment, err := g.parseURL(gr.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := g.getClient(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// There is no limit set for the size of an object from GCS
-	return copyReader(dst, rc, 0666, g.client.umask(), 0)
+	obj := client.Bucket(bucket).Object(object)
+	if fragment != "" {
+		generation, err := strconv.ParseInt(fragment, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		obj = obj.Generation(generation)
+	}
+	attrs, err := obj.Attrs(ctx)
+	if err == nil {
+		return newCheckResult(previous, strconv.FormatInt(attrs.Generation, 10)), nil
+	}
+	if err = gcsError(err); fragment != "" || !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	var objects []string
+	iter := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: strings.TrimSuffix(object, "/") + "/"})
+	for {
+		attrs, iterErr := iter.Next()
+		if iterErr == iterator.Done {
+			break
+		}
+		if iterErr != nil {
+			return nil, gcsError(iterErr)
+		}
+		objects = append(objects, attrs.Name+" "+strconv.FormatInt(attrs.Generation, 10))
+	}
+	if len(objects) == 0 {
+		return nil, err
+	}
+	return newCheckResult(previous, listingState(objects)), nil
+}
+
+// gcsError maps an error of the GCS client onto the sentinel errors, and
+// marks it as retryable if the client classifies it as transient.
+func gcsError(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return withSentinel(err, ErrNotFound)
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return withSentinel(err, ErrNotFound)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return withSentinel(err, ErrUnauthorized)
+		}
+	}
+	if storage.ShouldRetry(err) {
+		return &RetryableError{Err: err}
+	}
+	return err
 }
 
 func (g *GCSGetter) parseURL(u *url.URL) (bucket, path, fragment string, err error) {
@@ -34,12 +34,44 @@ type GitGetter struct {
 
 var lsRemoteSymRefRegexp = regexp.MustCompile(`ref: refs/heads/([^\s]+).*`)
 
-func (g *GitGetter) ClientMode(_ *url.URL) (ClientMode, error) {
+// Clone implements CloneableGetter.
+func (g *GitGetter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	return &clone
+}
+
+// Pin implements Pinner. The commit is checked out with the ref parameter
+// and shallow clones are disabled, since they can only fetch named refs.
+func (g *GitGetter) Pin(u *url.URL, version string) *url.URL {
+	pinned := *u
+	q := pinned.Query()
+	q.Set("ref", version)
+	q.Del("depth")
+	pinned.RawQuery = q.Encode()
+	return &pinned
+}
+
+func (g *GitGetter) ClientMode(u *url.URL) (ClientMode, error) {
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *GitGetter) ClientModeContext(_ context.Context, _ *GetterRequest) (ClientMode, error) {
 	return ClientModeDir, nil
 }
 
 func (g *GitGetter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *GitGetter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	if !isOSFS(gr.FS) || gr.Filter != nil {
+		return getThroughOS(ctx, gr, g.GetContext)
+	}
+
+	dst, u := gr.Dst, gr.URL
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -64,63 +96,26 @@ func (g *GitGetter) Get(dst string, u *url.URL) error {
 	}
 
 	// Extract some query parameters we use
-	var ref, sshKey string
+	u, params, err := takeParams(u, "ref", "sshkey", "depth")
+	if err != nil {
+		return err
+	}
+	ref, sshKey := params.Get("ref"), params.Get("sshkey")
 	depth := 0 // 0 means "don't use shallow clone"
-	q := u.Query()
-	if len(q) > 0 {
-		ref = q.Get("ref")
-		q.Del("ref")
-
-		sshKey = q.Get("sshkey")
-		q.Del("sshkey")
-
-		if n, err := strconv.Atoi(q.Get("depth")); err == nil {
-			depth = n
-		}
-		q.Del("depth")
-
-		// Copy the URL
-		newU := *u
-		u = &newU
-		u.RawQuery = q.Encode()
+	if n, err := strconv.Atoi(params.Get("depth")); err == nil {
+		depth = n
 	}
 
-	var sshKeyFile string
-	if sshKey != "" {
-		// Check that the git version is sufficiently new.
-		if err := checkGitVersion(ctx, "2.3"); err != nil {
-			return fmt.Errorf("Error using ssh key: %v", err)
-		}
-
-		// We have an SSH key - decode it.
-		raw, err := base64.StdEncoding.DecodeString(sshKey)
-		if err != nil {
-			return err
-		}
-
-		// Create a temp file for the key and ensure it is removed.
-		fh, err := os.CreateTemp("", "go-getter")
-		if err != nil {
-			return err
-		}
-		sshKeyFile = fh.Name()
+	sshKeyFile, err := writeSSHKey(ctx, sshKey)
+	if err != nil {
+		return err
+	}
+	if sshKeyFile != "" {
 		defer func() { _ = os.Remove(sshKeyFile) }()
-
-		// Set the permissions prior to writing the key material.
-		if err := os.Chmod(sshKeyFile, 0600); err != nil {
-			return err
-		}
-
-		// Write the raw key into the temp file.
-		_, err = fh.Write(raw)
-		_ = fh.Close()
-		if err != nil {
-			return err
-		}
 	}
 
 	// Clone or update the repository
-	_, err := os.Stat(dst)
+	_, err = os.Stat(dst)
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
@@ -130,23 +125,40 @@ func (g *GitGetter) Get(dst string, u *url.URL) error {
 		err = g.clone(ctx, dst, sshKeyFile, u, ref, depth)
 	}
 	if err != nil {
-		return err
+		return gitError(err)
 	}
 
 	// Next: check out the proper tag/branch if it is specified, and checkout
 	if ref != "" {
 		if err := g.checkout(ctx, dst, ref); err != nil {
-			return err
+			return gitError(err)
 		}
 	}
 
 	// Lastly, download any/all submodules.
-	return g.fetchSubmodules(ctx, dst, sshKeyFile, depth)
+	if err := g.fetchSubmodules(ctx, gr.Client, dst, sshKeyFile, depth); err != nil {
+		return gitError(err)
+	}
+
+	// Record the commit that ended up checked out.
+	commit, err := headCommit(ctx, dst)
+	if err != nil {
+		return err
+	}
+	gr.Client.setVersion(commit)
+	return nil
 }
 
 // GetFile for Git doesn't support updating at this time. It will download
 // the file every time.
 func (g *GitGetter) GetFile(dst string, u *url.URL) error {
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetFileContext implements GetterContext.
+func (g *GitGetter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+
 	td, tdcloser, err := mkdirTemp("", "getter")
 	if err != nil {
 		return err
@@ -159,7 +171,7 @@ func (g *GitGetter) GetFile(dst string, u *url.URL) error {
 	u.Path = filepath.Dir(u.Path)
 
 	// Get the full repository
-	if err := g.Get(td, u); err != nil {
+	if err := g.GetContext(ctx, &GetterRequest{Client: gr.Client, Dst: td, URL: u}); err != nil {
 		return err
 	}
 
@@ -170,7 +182,138 @@ func (g *GitGetter) GetFile(dst string, u *url.URL) error {
 	}
 
 	fg := &FileGetter{Copy: true}
-	return fg.GetFile(dst, u)
+	return fg.GetFileContext(ctx, &GetterRequest{Client: gr.Client, Dst: dst, URL: u, FS: gr.FS})
+}
+
+// CheckContext implements Checker. The state is the commit the ref of the
+// source, or the default branch if there is none, points to on the remote.
+func (g *GitGetter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git must be available and on the PATH")
+	}
+
+	u, params, err := takeParams(gr.URL, "ref", "sshkey", "depth")
+	if err != nil {
+		return nil, err
+	}
+	ref, sshKey := params.Get("ref"), params.Get("sshkey")
+
+	sshKeyFile, err := writeSSHKey(ctx, sshKey)
+	if err != nil {
+		return nil, err
+	}
+	if sshKeyFile != "" {
+		defer func() { _ = os.Remove(sshKeyFile) }()
+	}
+
+	pattern := ref
+	if pattern == "" {
+		pattern = "HEAD"
+	}
+	// Peeled tags are only listed when asked for explicitly.
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--", u.String(), pattern, pattern+"^{}")
+	setupGitEnv(cmd, sshKeyFile)
+	out, err := getCommandOutput(cmd)
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if commit, name, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			refs[name] = commit
+		}
+	}
+
+	// Tags take precedence over branches, like they do for checkout, and
+	// annotated tags are peeled to their commit.
+	candidates := []string{pattern}
+	if ref != "" {
+		candidates = append(candidates, "refs/tags/"+ref+"^{}", "refs/tags/"+ref, "refs/heads/"+ref)
+	}
+	for _, name := range candidates {
+		if commit, ok := refs[name]; ok {
+			return newCheckResult(previous, commit), nil
+		}
+	}
+
+	// Commits can't be looked up remotely, but they never change either.
+	if gitCommitIDRegex.MatchString(ref) {
+		return newCheckResult(previous, strings.ToLower(ref)), nil
+	}
+	return nil, fmt.Errorf("%w: ref %q of %s", ErrNotFound, ref, RedactURL(u))
+}
+
+// gitNotFoundErrors and gitUnauthorizedErrors are lowercased messages in
+// the output of git that map onto ErrNotFound and ErrUnauthorized.
+var gitNotFoundErrors = []string{
+	"repository not found",
+	"' not found",
+	"does not exist",
+	"does not appear to be a git repository",
+	"couldn't find remote ref",
+	"not found in upstream",
+	"did not match any file(s) known to git",
+	"unknown revision",
+	"returned error: 404",
+}
+
+var gitUnauthorizedErrors = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"permission denied (publickey",
+	"invalid username or password",
+	"returned error: 401",
+	"returned error: 403",
+}
+
+// gitError maps err, returned by a git command, onto the sentinel errors and
+// marks it as retryable if it is a transient network failure.
+func gitError(err error) error {
+	if mapped := commandError(err, gitNotFoundErrors, gitUnauthorizedErrors); mapped != err {
+		return mapped
+	}
+	return gitRetryableError(err)
+}
+
+// gitNetworkErrors are lowercased messages in the output of git that point
+// at a transient network failure rather than a bad repository or ref.
+var gitNetworkErrors = []string{
+	"could not resolve host",
+	"connection reset",
+	"connection refused",
+	"connection timed out",
+	"operation timed out",
+	"failed to connect",
+	"early eof",
+	"the remote end hung up unexpectedly",
+	"rpc failed",
+	"tls connection was non-properly terminated",
+	"unexpected disconnect",
+	"http 429",
+	"returned error: 429",
+	"returned error: 502",
+	"returned error: 503",
+	"returned error: 504",
+}
+
+// gitRetryableError marks err as retryable if the git output it carries
+// points at a transient network failure.
+func gitRetryableError(err error) error {
+	msg := strings.ToLower(err.Error())
+	for _, s := range gitNetworkErrors {
+		if strings.Contains(msg, s) {
+			return &RetryableError{Err: err}
+		}
+	}
+	return err
 }
 
 func (g *GitGetter) checkout(ctx context.Context, dst string, ref string) error {
@@ -204,6 +347,18 @@ func resolveCheckoutRef(ctx context.Context, dst, ref string) (string, error) {
 	return "", fmt.Errorf("invalid ref: %q", ref)
 }
 
+// headCommit returns the full SHA of the commit checked out in dst.
+func headCommit(ctx context.Context, dst string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = dst
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve checked out commit: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // gitCommitIDRegex is a pattern intended to match strings that seem
 // "likely to be" git commit IDs, rather than named refs. This cannot be
 // an exact decision because it's valid to name a branch or tag after a series
@@ -324,9 +479,9 @@ func (g *GitGetter) update(ctx context.Context, dst, sshKeyFile string, u *url.U
 }
 
 // fetchSubmodules downloads any configured submodules recursively.
-func (g *GitGetter) fetchSubmodules(ctx context.Context, dst, sshKeyFile string, depth int) error {
-	if g.client != nil {
-		g.client.DisableSymlinks = true
+func (g *GitGetter) fetchSubmodules(ctx context.Context, c *Client, dst, sshKeyFile string, depth int) error {
+	if c != nil {
+		c.DisableSymlinks = true
 	}
 	args := []string{"submodule", "update", "--init", "--recursive"}
 	if depth > 0 {
@@ -352,6 +507,49 @@ func findRemoteDefaultBranch(ctx context.Context, u *url.URL) string {
 	return matches[len(matches)-1]
 }
 
+// writeSSHKey writes the base64 encoded key of the sshkey parameter to a
+// temporary file for ssh, and returns its path. The caller must remove it.
+// It returns an empty path if sshKey is empty.
+func writeSSHKey(ctx context.Context, sshKey string) (string, error) {
+	if sshKey == "" {
+		return "", nil
+	}
+
+	// Check that the git version is sufficiently new.
+	if err := checkGitVersion(ctx, "2.3"); err != nil {
+		return "", fmt.Errorf("Error using ssh key: %v", err)
+	}
+
+	// We have an SSH key - decode it.
+	raw, err := base64.StdEncoding.DecodeString(sshKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Create a temp file for the key.
+	fh, err := os.CreateTemp("", "go-getter")
+	if err != nil {
+		return "", err
+	}
+	sshKeyFile := fh.Name()
+
+	// Set the permissions prior to writing the key material.
+	if err := os.Chmod(sshKeyFile, 0600); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(sshKeyFile)
+		return "", err
+	}
+
+	// Write the raw key into the temp file.
+	_, err = fh.Write(raw)
+	_ = fh.Close()
+	if err != nil {
+		_ = os.Remove(sshKeyFile)
+		return "", err
+	}
+	return sshKeyFile, nil
+}
+
 // setupGitEnv sets up the environment for the given command. This is used to
 // pass configuration data to git and ssh and enables advanced cloning methods.
 func setupGitEnv(cmd *exec.Cmd, sshKeyFile string) {
@@ -55,6 +55,37 @@ func TestGitGetter(t *testing.T) {
 	}
 }
 
+func TestGitGetter_resultVersion(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	dst := filepath.Join(t.TempDir(), "target")
+
+	repo := testGitRepo(t, "result-version")
+	repo.commitFile("foo.txt", "hello")
+	commit, err := repo.latestCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		Src:  "git::" + repo.url.String(),
+		Dst:  dst,
+		Mode: ClientModeDir,
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Getter != "git" {
+		t.Fatalf("bad getter: %q", result.Getter)
+	}
+	if result.Version != commit {
+		t.Fatalf("expected version %q, got %q", commit, result.Version)
+	}
+}
+
 func TestGitGetter_branch(t *testing.T) {
 	if !testHasGit {
 		t.Skip("git not found, skipping")
@@ -1367,6 +1398,46 @@ func TestGitGetter_BadRef(t *testing.T) {
 	}
 }
 
+func TestGitGetter_contextCancel(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "cancel")
+	repo.commitFile("foo.txt", "hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := new(GitGetter)
+	dst := filepath.Join(t.TempDir(), "target")
+	err := g.GetContext(ctx, &GetterRequest{Dst: dst, URL: repo.url})
+	if err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestGitGetter_retryableError(t *testing.T) {
+	cases := []struct {
+		Output    string
+		Retryable bool
+	}{
+		{"fatal: unable to access 'https://example.com/foo.git/': Could not resolve host: example.com", true},
+		{"error: RPC failed; curl 56 Recv failure: Connection reset by peer", true},
+		{"fatal: the remote end hung up unexpectedly", true},
+		{"fatal: repository 'https://example.com/foo.git/' not found", false},
+		{"error: pathspec 'nope' did not match any file(s) known to git", false},
+	}
+
+	for _, tc := range cases {
+		err := gitRetryableError(fmt.Errorf("/usr/bin/git exited with 128: %s", tc.Output))
+		var retryable *RetryableError
+		if got := errors.As(err, &retryable); got != tc.Retryable {
+			t.Fatalf("%q: expected retryable %t, got %t", tc.Output, tc.Retryable, got)
+		}
+	}
+}
+
 // gitRepo is a helper struct which controls a single temp git repo.
 type gitRepo struct {
 	t   *testing.T
@@ -10,7 +10,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
 	urlhelper "github.com/hashicorp/go-getter/helper/url"
@@ -26,12 +28,42 @@ type HgGetter struct {
 	Timeout time.Duration
 }
 
-func (g *HgGetter) ClientMode(_ *url.URL) (ClientMode, error) {
+// Clone implements CloneableGetter.
+func (g *HgGetter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	return &clone
+}
+
+// Pin implements Pinner.
+func (g *HgGetter) Pin(u *url.URL, version string) *url.URL {
+	pinned := *u
+	q := pinned.Query()
+	q.Set("rev", version)
+	pinned.RawQuery = q.Encode()
+	return &pinned
+}
+
+func (g *HgGetter) ClientMode(u *url.URL) (ClientMode, error) {
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *HgGetter) ClientModeContext(_ context.Context, _ *GetterRequest) (ClientMode, error) {
 	return ClientModeDir, nil
 }
 
 func (g *HgGetter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *HgGetter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	if !isOSFS(gr.FS) || gr.Filter != nil {
+		return getThroughOS(ctx, gr, g.GetContext)
+	}
+
+	dst, u := gr.Dst, gr.URL
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -53,14 +85,11 @@ func (g *HgGetter) Get(dst string, u *url.URL) error {
 	}
 
 	// Extract some query parameters we use
-	var rev string
-	q := newURL.Query()
-	if len(q) > 0 {
-		rev = q.Get("rev")
-		q.Del("rev")
-
-		newURL.RawQuery = q.Encode()
+	newURL, params, err := takeParams(newURL, "rev")
+	if err != nil {
+		return err
 	}
+	rev := params.Get("rev")
 
 	_, err = os.Stat(dst)
 	if err != nil && !os.IsNotExist(err) {
@@ -68,20 +97,108 @@ func (g *HgGetter) Get(dst string, u *url.URL) error {
 	}
 	if err != nil {
 		if err := g.clone(ctx, dst, newURL); err != nil {
-			return err
+			return hgError(err)
 		}
 	}
 
 	if err := g.pull(ctx, dst, newURL); err != nil {
+		return hgError(err)
+	}
+
+	if err := g.update(ctx, dst, newURL, rev); err != nil {
+		return hgError(err)
+	}
+
+	// Record the changeset that ended up checked out.
+	changeset, err := g.changeset(ctx, dst)
+	if err != nil {
 		return err
 	}
+	gr.Client.setVersion(changeset)
+	return nil
+}
 
-	return g.update(ctx, dst, newURL, rev)
+// CheckContext implements Checker. The state is the changeset the rev of
+// the source, or the tip if there is none, identifies on the remote.
+func (g *HgGetter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	if _, err := exec.LookPath("hg"); err != nil {
+		return nil, fmt.Errorf("hg must be available and on the PATH")
+	}
+
+	newURL, err := urlhelper.Parse(gr.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	if fixWindowsDrivePath(newURL) {
+		newURL.Path = fmt.Sprintf("/%s", newURL.Path)
+	}
+	newURL, params, err := takeParams(newURL, "rev")
+	if err != nil {
+		return nil, err
+	}
+	rev := params.Get("rev")
+
+	// --debug makes identify print the full changeset id.
+	args := []string{"identify", "--debug", "--id"}
+	if rev != "" {
+		args = append(args, "-r", rev)
+	}
+	args = append(args, "--", newURL.String())
+	out, err := getCommandOutput(exec.CommandContext(ctx, "hg", args...))
+	if err != nil {
+		return nil, hgError(err)
+	}
+
+	// The id is printed last, after any debug messages.
+	lines := strings.Fields(out)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("hg identify printed no changeset for %s", RedactURL(newURL))
+	}
+	return newCheckResult(previous, lines[len(lines)-1]), nil
+}
+
+// hgNotFoundErrors and hgUnauthorizedErrors are lowercased messages in the
+// output of hg that map onto ErrNotFound and ErrUnauthorized. A missing
+// repository names its path, so it is matched by hgRepositoryNotFound.
+var hgNotFoundErrors = []string{
+	"there is no mercurial repository here",
+	"unknown revision",
+	"http error 404",
+}
+
+var hgUnauthorizedErrors = []string{
+	"authorization failed",
+	"authorization required",
+	"http error 401",
+	"http error 403",
+}
+
+var hgRepositoryNotFound = regexp.MustCompile(`abort: repository .+ not found`)
+
+// hgError maps err, returned by an hg command, onto the sentinel errors.
+func hgError(err error) error {
+	if hgRepositoryNotFound.MatchString(err.Error()) {
+		return withSentinel(err, ErrNotFound)
+	}
+	return commandError(err, hgNotFoundErrors, hgUnauthorizedErrors)
 }
 
 // GetFile for Hg doesn't support updating at this time. It will download
 // the file every time.
 func (g *HgGetter) GetFile(dst string, u *url.URL) error {
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetFileContext implements GetterContext.
+func (g *HgGetter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+
 	// Create a temporary directory to store the full source. This has to be
 	// a non-existent directory.
 	td, tdcloser, err := mkdirTemp("", "getter")
@@ -101,7 +218,7 @@ func (g *HgGetter) GetFile(dst string, u *url.URL) error {
 	}
 
 	// Get the full repository
-	if err := g.Get(td, u); err != nil {
+	if err := g.GetContext(ctx, &GetterRequest{Client: gr.Client, Dst: td, URL: u}); err != nil {
 		return err
 	}
 
@@ -112,7 +229,7 @@ func (g *HgGetter) GetFile(dst string, u *url.URL) error {
 	}
 
 	fg := &FileGetter{Copy: true, getter: g.getter}
-	return fg.GetFile(dst, u)
+	return fg.GetFileContext(ctx, &GetterRequest{Client: gr.Client, Dst: dst, URL: u, FS: gr.FS})
 }
 
 func (g *HgGetter) clone(ctx context.Context, dst string, u *url.URL) error {
@@ -137,6 +254,18 @@ func (g *HgGetter) update(ctx context.Context, dst string, u *url.URL, rev strin
 	return getRunCommand(cmd)
 }
 
+// changeset returns the full id of the changeset checked out in dst.
+func (g *HgGetter) changeset(ctx context.Context, dst string) (string, error) {
+	cmd := exec.CommandContext(ctx, "hg", "log", "-r", ".", "--template", "{node}")
+	cmd.Dir = dst
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve checked out changeset: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func fixWindowsDrivePath(u *url.URL) bool {
 	// hg assumes a file:/// prefix for Windows drive letter file paths.
 	// (e.g. file:///c:/foo/bar)
@@ -5,7 +5,6 @@ package getter
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -14,10 +13,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
-
-	"github.com/hashicorp/go-cleanhttp"
 )
 
 // HttpGetter is a Getter implementation that will download from an HTTP
@@ -90,7 +88,22 @@ type HttpGetter struct {
 	XTerraformGetDisabled bool
 }
 
+// Clone implements CloneableGetter.
+func (g *HttpGetter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	clone.Header = g.Header.Clone()
+	return &clone
+}
+
 func (g *HttpGetter) ClientMode(u *url.URL) (ClientMode, error) {
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *HttpGetter) ClientModeContext(ctx context.Context, gr *GetterRequest) (ClientMode, error) {
+	u := gr.URL
+
 	if strings.HasSuffix(u.Path, "/") {
 		return ClientModeDir, nil
 	}
@@ -105,6 +118,7 @@ const (
 	xTerraformGetLimitCurrentValue contextKey = 2
 	httpClientValue                contextKey = 3
 	httpMaxBytesValue              contextKey = 4
+	rateLimiterValue               contextKey = 5
 )
 
 func xTerraformGetDisabled(ctx context.Context) bool {
@@ -139,6 +153,24 @@ func httpClientFromContext(ctx context.Context) *http.Client {
 	return value
 }
 
+// httpClient returns the HTTP client to make requests with for the client
+// c: the getter's own Client if set, then the one passed along in ctx, and
+// finally the package default matching the Insecure setting of c. It
+// never modifies the getter or the package defaults, which may be in use
+// by other clients.
+func (g *HttpGetter) httpClient(ctx context.Context, c *Client) *http.Client {
+	if g.Client != nil {
+		return g.Client
+	}
+	if client := httpClientFromContext(ctx); client != nil {
+		return client
+	}
+	if c != nil && c.Insecure {
+		return insecureHttpClient
+	}
+	return httpClient
+}
+
 func httpMaxBytesFromContext(ctx context.Context) int64 {
 	value, ok := ctx.Value(httpMaxBytesValue).(int64)
 	if !ok {
@@ -168,7 +200,12 @@ func newLimitedWrappedReaderCloser(r io.ReadCloser, limit int64) io.ReadCloser {
 }
 
 func (g *HttpGetter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *HttpGetter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
 
 	// Optionally disable any X-Terraform-Get redirects. This is reccomended for usage of
 	// this client outside of Terraform's. This feature is likely not required if the
@@ -217,24 +254,10 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 		}
 	}
 
-	// If the HTTP client is nil, check if there is one available in the context,
-	// otherwise create one using cleanhttp's default transport.
-	if g.Client == nil {
-		if client := httpClientFromContext(ctx); client != nil {
-			g.Client = client
-		} else {
-			client := httpClient
-			if g.client != nil && g.client.Insecure {
-				insecureTransport := cleanhttp.DefaultTransport()
-				insecureTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-				client.Transport = insecureTransport
-			}
-			g.Client = client
-		}
-	}
+	client := g.httpClient(ctx, gr.Client)
 
 	// Pass along the configured HTTP client in the context for usage with the X-Terraform-Get feature.
-	ctx = context.WithValue(ctx, httpClientValue, g.Client)
+	ctx = context.WithValue(ctx, httpClientValue, client)
 
 	// Add terraform-get to the parameter.
 	q := u.Query()
@@ -259,9 +282,9 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 		req.Header = g.Header.Clone()
 	}
 
-	resp, err := g.Client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return httpTransportError(err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -272,7 +295,7 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+		return httpStatusError(resp)
 	}
 
 	if disabled := xTerraformGetDisabled(ctx); disabled {
@@ -295,17 +318,23 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 
 	// If there is a subdir component, then we download the root separately
 	// into a temporary directory, then copy over the proper subdir.
-	source, subDir := SourceDirSubdir(source)
+	s, err := ParseSource(source)
+	if err != nil {
+		return err
+	}
+	subDir := s.Subdir
+	s.Subdir = ""
+	source = s.String()
 
 	var opts []ClientOption
 
 	// Check if the protocol was switched to one which was not configured.
-	if g.client != nil && g.client.Getters != nil {
+	if gr.Client != nil && gr.Client.Getters != nil {
 		// We must first use the Detectors provided, because `X-Terraform-Get does
 		// not necessarily return a valid URL. We can replace the source string
 		// here, since the detectors would have been called immediately during the
 		// next Get anyway.
-		source, err = Detect(source, g.client.Pwd, g.client.Detectors)
+		source, err = Detect(source, gr.Client.Pwd, gr.Client.Detectors)
 		if err != nil {
 			return err
 		}
@@ -319,7 +348,7 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 
 		// Otherwise, all default getters are allowed.
 		if protocol != "" {
-			_, allowed := g.client.Getters[protocol]
+			_, allowed := gr.Client.Getters[protocol]
 			if !allowed {
 				return fmt.Errorf("no getter available for X-Terraform-Get source protocol: %q", protocol)
 			}
@@ -327,8 +356,8 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 	}
 
 	// Add any getter client options.
-	if g.client != nil {
-		opts = g.client.Options
+	if gr.Client != nil {
+		opts = gr.Client.Options
 	}
 
 	// If the client is nil, we know we're using the HttpGetter directly. In
@@ -338,7 +367,7 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 	// This prevents all default getters from being allowed when only using the
 	// HttpGetter directly. To enable protocol switching, a client "wrapper" must
 	// be used.
-	if g.client == nil {
+	if gr.Client == nil {
 		switch {
 		case subDir != "":
 			// If there's a subdirectory, we will also need a file getter to
@@ -356,6 +385,8 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 		}
 	}
 
+	gr.Client.observer().OnXTerraformGetRedirect(RedactURL(u), redactSource(source))
+
 	// Ensure we pass along the context we constructed in this function.
 	//
 	// This is especially important to enforce a limit on X-Terraform-Get redirects
@@ -364,9 +395,12 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 
 	if subDir != "" {
 		// We have a subdir, time to jump some hoops
-		return g.getSubdir(ctx, dst, source, subDir, opts...)
+		return g.getSubdir(ctx, gr.Client, gr.FS, dst, source, subDir, gr.Filter, opts...)
 	}
 
+	// Write the download to the same filesystem as ours, with our filter.
+	opts = append(opts, WithDestinationFS(gr.FS), withFilter(gr.Filter))
+
 	// Note: this allows the protocol to be switched to another configured getters.
 	return Get(dst, source, opts...)
 }
@@ -378,7 +412,13 @@ func (g *HttpGetter) Get(dst string, u *url.URL) error {
 // falsely identified as being replaced, or corrupted with extra bytes
 // appended.
 func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
-	ctx := g.Context()
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: src})
+}
+
+// GetFileContext implements GetterContext.
+func (g *HttpGetter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, src := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
 
 	// Optionally enforce a maxiumum HTTP response body size.
 	if g.MaxBytes > 0 {
@@ -392,24 +432,17 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 		}
 	}
 	// Create all the parent directories if needed
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), gr.Client.mode(0755)); err != nil {
 		return err
 	}
 
-	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE, g.client.mode(0666))
+	f, err := fsys.OpenFile(dst, os.O_RDWR|os.O_CREATE, gr.Client.mode(0666))
 	if err != nil {
 		return err
 	}
 	defer func() { _ = f.Close() }()
 
-	if g.Client == nil {
-		g.Client = httpClient
-		if g.client != nil && g.client.Insecure {
-			insecureTransport := cleanhttp.DefaultTransport()
-			insecureTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-			g.Client.Transport = insecureTransport
-		}
-	}
+	client := g.httpClient(ctx, gr.Client)
 
 	var (
 		currentFileSize int64
@@ -436,7 +469,7 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 		if g.Header != nil {
 			req.Header = g.Header.Clone()
 		}
-		headResp, err := g.Client.Do(req)
+		headResp, err := client.Do(req)
 		if err == nil {
 			_ = headResp.Body.Close()
 			if headResp.StatusCode == 200 {
@@ -448,6 +481,8 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 							currentFileSize = fi.Size()
 							if currentFileSize >= headResp.ContentLength {
 								// file already present
+								gr.Client.setVersion(headResp.Header.Get("ETag"))
+								setContentFromHeader(gr.Client, headResp.Header)
 								return nil
 							}
 						}
@@ -476,16 +511,16 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", currentFileSize))
 	}
 
-	resp, err := g.Client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return httpTransportError(err)
 	}
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusPartialContent:
 		// all good
 	default:
 		_ = resp.Body.Close()
-		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+		return httpStatusError(resp)
 	}
 
 	body := resp.Body
@@ -494,10 +529,10 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 		body = newLimitedWrappedReaderCloser(body, maxBytes)
 	}
 
-	if g.client != nil && g.client.ProgressListener != nil {
+	if gr.Client != nil && gr.Client.ProgressListener != nil {
 		// track download
 		fn := filepath.Base(src.EscapedPath())
-		body = g.client.ProgressListener.TrackProgress(fn, currentFileSize, currentFileSize+resp.ContentLength, resp.Body)
+		body = gr.Client.ProgressListener.TrackProgress(fn, currentFileSize, currentFileSize+resp.ContentLength, resp.Body)
 	}
 	defer func() { _ = body.Close() }()
 
@@ -505,53 +540,191 @@ func (g *HttpGetter) GetFile(dst string, src *url.URL) error {
 	if err == nil && n < resp.ContentLength {
 		err = io.ErrShortWrite
 	}
-	return err
+	if err != nil {
+		return httpTransportError(err)
+	}
+
+	gr.Client.setVersion(resp.Header.Get("ETag"))
+	setContentFromHeader(gr.Client, resp.Header)
+	return nil
 }
 
-// getSubdir downloads the source into the destination, but with
-// the proper subdir.
-func (g *HttpGetter) getSubdir(ctx context.Context, dst, source, subDir string, opts ...ClientOption) error {
-	// Create a temporary directory to store the full source. This has to be
-	// a non-existent directory.
-	td, tdcloser, err := mkdirTemp("", "getter")
+// setContentFromHeader records the Content-Type and Content-Disposition
+// file name of a response on the GetResult of the client c.
+func setContentFromHeader(c *Client, h http.Header) {
+	c.setContent(h.Get("Content-Type"), contentDispositionFilename(h.Get("Content-Disposition")))
+}
+
+// OpenContext implements Opener.
+func (g *HttpGetter) OpenContext(ctx context.Context, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	u := gr.URL
+
+	if g.Netrc {
+		// Add auth from netrc if we can
+		if err := addAuthFromNetrc(u); err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+
+	// The read timeout covers reading the body, so it is only cancelled
+	// once the body is closed.
+	var readCtx context.Context
+	var cancel context.CancelFunc
+	if g.ReadTimeout > 0 {
+		readCtx, cancel = context.WithTimeout(ctx, g.ReadTimeout)
+	} else {
+		readCtx, cancel = context.WithCancel(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(readCtx, "GET", u.String(), nil)
 	if err != nil {
-		return err
+		cancel()
+		return nil, Metadata{}, err
+	}
+	if g.Header != nil {
+		req.Header = g.Header.Clone()
 	}
-	defer func() { _ = tdcloser.Close() }()
 
-	// Download that into the given directory
-	if err := Get(td, source, opts...); err != nil {
-		return err
+	resp, err := g.httpClient(ctx, gr.Client).Do(req)
+	if err != nil {
+		cancel()
+		return nil, Metadata{}, httpTransportError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, Metadata{}, httpStatusError(resp)
+	}
+
+	var body io.Reader = resp.Body
+	if g.MaxBytes > 0 {
+		body = io.LimitReader(body, g.MaxBytes)
+	}
+
+	gr.Client.setVersion(resp.Header.Get("ETag"))
+	setContentFromHeader(gr.Client, resp.Header)
+
+	md := Metadata{
+		Name:        contentDispositionFilename(resp.Header.Get("Content-Disposition")),
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	return &limitedWrappedReaderCloser{
+		underlying: body,
+		closeFn: func() error {
+			defer cancel()
+			return resp.Body.Close()
+		},
+	}, md, nil
+}
+
+// CheckContext implements Checker. The state is the ETag of the source, or
+// its Last-Modified date if the server doesn't send an ETag.
+func (g *HttpGetter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	u := gr.URL
+
+	if g.Netrc {
+		// Add auth from netrc if we can
+		if err := addAuthFromNetrc(u); err != nil {
+			return nil, err
+		}
 	}
 
-	// Process any globbing
-	sourcePath, err := SubdirGlob(td, subDir)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", u.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if g.Header != nil {
+		req.Header = g.Header.Clone()
 	}
 
-	// Make sure the subdir path actually exists
-	if _, err := os.Stat(sourcePath); err != nil {
-		return fmt.Errorf("downloading %s: %s", source, err)
+	resp, err := g.httpClient(ctx, gr.Client).Do(req)
+	if err != nil {
+		return nil, httpTransportError(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError(resp)
 	}
 
-	// Copy the subdirectory into our actual destination.
-	if err := os.RemoveAll(dst); err != nil {
-		return err
+	state := resp.Header.Get("ETag")
+	if state == "" {
+		state = resp.Header.Get("Last-Modified")
+	}
+	if state == "" {
+		return nil, fmt.Errorf("%s has neither an ETag nor a Last-Modified header", RedactURL(u))
 	}
+	return newCheckResult(previous, state), nil
+}
 
-	// Make the final destination
-	if err := os.MkdirAll(dst, g.client.mode(0755)); err != nil {
-		return err
+// httpStatusError returns an *HTTPStatusError for an unexpected response
+// code. Server errors and rate limiting are retryable, honoring any
+// Retry-After header.
+func httpStatusError(resp *http.Response) error {
+	err := &HTTPStatusError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		err.URL = RedactURL(resp.Request.URL)
 	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &RetryableError{Err: err, RetryAfter: retryAfter(resp.Header)}
+	}
+	return err
+}
 
-	var disableSymlinks bool
+// httpTransportError marks an error from sending a request or reading its
+// response as retryable if it is a transient network failure.
+func httpTransportError(err error) error {
+	if isTransientNetError(err) {
+		return &RetryableError{Err: err}
+	}
+	return err
+}
 
-	if g.client != nil && g.client.DisableSymlinks {
-		disableSymlinks = true
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns zero if the header is missing or
+// invalid.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// getSubdir downloads the source into the destination, but with
+// the proper subdir, for the client c. Only the files of the subdir
+// selected by filter are copied.
+func (g *HttpGetter) getSubdir(ctx context.Context, c *Client, fsys WritableFS, dst, source, subDir string, filter *Filter, opts ...ClientOption) error {
+	fsys = destFS(fsys)
+
+	// Create a temporary directory to store the full source. This has to be
+	// a non-existent directory.
+	td, tdcloser, err := mkdirTemp("", "getter")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tdcloser.Close() }()
+
+	// Download that into the given directory, which is on the OS filesystem.
+	// The filter applies to the subdir, so it is left to the copy below.
+	if err := Get(td, source, append(opts, WithDestinationFS(OSFS{}), withFilter(nil))...); err != nil {
+		return err
 	}
 
-	return copyDir(ctx, dst, sourcePath, false, disableSymlinks, g.client.umask())
+	// Copy the subdirectory into our actual destination, processing any
+	// globbing.
+	return c.copySubdir(ctx, fsys, dst, td, subDir, filter)
 }
 
 // parseMeta looks for the first meta tag in the given reader that
@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FilenameContext implements FilenameReporter. It asks the server for the
+// Content-Disposition of the file with a HEAD request. Servers that don't
+// support HEAD or fail to answer it suggest no name, and nothing is asked
+// when DoNotCheckHeadFirst is set.
+func (g *HttpGetter) FilenameContext(ctx context.Context, gr *GetterRequest) (string, error) {
+	if g.DoNotCheckHeadFirst {
+		return "", nil
+	}
+	if g.HeadFirstTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.HeadFirstTimeout)
+		defer cancel()
+	}
+
+	// Credentials from netrc are only added to a copy of the URL, which is
+	// then downloaded as given.
+	u := *gr.URL
+	if g.Netrc {
+		if err := addAuthFromNetrc(&u); err != nil {
+			return "", err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if g.Header != nil {
+		req.Header = g.Header.Clone()
+	}
+
+	// The name is only a hint, so the download is left to report errors.
+	resp, err := g.httpClient(ctx, gr.Client).Do(req)
+	if err != nil {
+		return "", nil
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+	return contentDispositionFilename(resp.Header.Get("Content-Disposition")), nil
+}
+
+// contentDispositionFilename returns the base name of the file name of a
+// Content-Disposition header value (RFC 6266), or "" if it has none. The
+// UTF-8 "filename*" parameter wins over "filename".
+func contentDispositionFilename(v string) string {
+	if v == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(v)
+	if err != nil {
+		return ""
+	}
+
+	// The name is only a suggestion, it must not select a directory.
+	name := path.Base(strings.ReplaceAll(params["filename"], `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
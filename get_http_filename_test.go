@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// testDispositionServer serves name as the Content-Disposition filename of
+// the content of the fixture at path, counting the GET requests. HEAD
+// requests are rejected if the "nohead" parameter is set, and their
+// connection is closed if the "headfail" parameter is set.
+func testDispositionServer(t *testing.T, path, name string, gets *int32) *httptest.Server {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(fixtureDir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead && req.URL.Query().Get("nohead") != "" {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if req.Method == http.MethodHead && req.URL.Query().Get("headfail") != "" {
+			conn, _, err := rw.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		if req.Method == http.MethodGet {
+			atomic.AddInt32(gets, 1)
+		}
+		rw.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		_, _ = rw.Write(content)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestHttpGetter_FilenameContext_archive(t *testing.T) {
+	var gets int32
+	s := testDispositionServer(t, "decompress-tgz/single.tar.gz", "x.tar.gz", &gets)
+
+	// The archive is picked from the name the server suggests.
+	dst := t.TempDir()
+	if err := GetAny(dst, s.URL+"/download?id=1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "file")); err != nil {
+		t.Fatalf("expected the archive to be unpacked: %s", err)
+	}
+
+	// Unless unpacking is disabled.
+	dst = t.TempDir()
+	if err := GetAny(dst, s.URL+"/download?id=1&archive=false"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "x.tar.gz")); err != nil {
+		t.Fatalf("expected the archive to be kept: %s", err)
+	}
+}
+
+func TestHttpGetter_FilenameContext_checksum(t *testing.T) {
+	var gets int32
+	s := testDispositionServer(t, "basic-file/foo.txt", "hello.txt", &gets)
+
+	// The file is downloaded under its final name, so the second download
+	// finds it and skips it.
+	dst := t.TempDir()
+	src := s.URL + "/download?id=1&checksum=sha256:66a045b452102c59d840ec097d59d9467e13a3f34f6494e539ffd32c1bb35f18"
+	for range 2 {
+		if err := GetAny(dst, src); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if gets := atomic.LoadInt32(&gets); gets != 1 {
+		t.Fatalf("expected 1 download, got %d", gets)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "Hello\n")
+
+	// Without HEAD, the name is only known once the file is downloaded.
+	dst = t.TempDir()
+	if err := GetAny(dst, s.URL+"/download?nohead=1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "Hello\n")
+}
+
+func TestHttpGetter_FilenameContext_headError(t *testing.T) {
+	var gets int32
+	s := testDispositionServer(t, "basic-file/foo.txt", "hello.txt", &gets)
+
+	// A failing HEAD request only loses the hint, not the download.
+	dst := t.TempDir()
+	if err := GetAny(dst, s.URL+"/download?headfail=1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "Hello\n")
+
+	for _, q := range []string{"nohead=1", "headfail=1"} {
+		name, err := new(HttpGetter).FilenameContext(context.Background(), &GetterRequest{URL: testURL(s.URL + "/download?" + q)})
+		if err != nil || name != "" {
+			t.Fatalf("%s: expected no name and no error, got %q, %v", q, name, err)
+		}
+	}
+}
+
+func TestContentDispositionFilename(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected string
+	}{
+		{"", ""},
+		{"inline", ""},
+		{`attachment; filename="foo.tar.gz"`, "foo.tar.gz"},
+		{`attachment; filename*=UTF-8''f%C3%B6%C3%B6.zip`, "föö.zip"},
+		{`attachment; filename="../../etc/passwd"`, "passwd"},
+		{`attachment; filename="C:\\temp\\foo.zip"`, "foo.zip"},
+		{`attachment; filename=".."`, ""},
+		{`attachment; filename="`, ""},
+	}
+
+	for _, tc := range cases {
+		if actual := contentDispositionFilename(tc.Input); actual != tc.Expected {
+			t.Errorf("%s: got %q, expected %q", tc.Input, actual, tc.Expected)
+		}
+	}
+}
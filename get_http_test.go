@@ -15,6 +15,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -334,6 +335,60 @@ func TestHttpGetter_file(t *testing.T) {
 	assertContents(t, dst, "Hello\n")
 }
 
+func TestHttpGetter_resultVersion(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	dst := filepath.Join(t.TempDir(), "test-file")
+	client := &Client{
+		Src:  fmt.Sprintf("http://%s/etag", ln.Addr().String()),
+		Dst:  dst,
+		Mode: ClientModeFile,
+	}
+
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Version != `"hello-etag"` {
+		t.Fatalf("bad version: %q", result.Version)
+	}
+	if result.Files != 1 || result.Bytes != int64(len("Hello\n")) {
+		t.Fatalf("bad result: %#v", result)
+	}
+}
+
+func TestHttpGetter_requestClient(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	// The getter reads the settings of the request's client, and never
+	// had SetClient called.
+	g := new(HttpGetter)
+	c := &Client{Umask: 0077, result: new(GetResult)}
+	u, err := url.Parse(fmt.Sprintf("http://%s/etag", ln.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "test-file")
+	if err := g.GetFileContext(context.Background(), &GetterRequest{Client: c, Dst: dst, URL: u}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if c.result.Version != `"hello-etag"` {
+		t.Fatalf("bad version: %q", c.result.Version)
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(dst)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Fatalf("bad mode: %s", fi.Mode())
+		}
+	}
+}
+
 // TestHttpGetter_http2server tests that http.Request is not reused
 // between HEAD & GET, which would lead to race condition in HTTP/2.
 // This test is only meaningful for the race detector (go test -race).
@@ -912,7 +967,9 @@ func testHttpServer(t *testing.T) net.Listener {
 	}
 
 	mux := http.NewServeMux()
+	mux.HandleFunc("/attachment", testHttpHandlerAttachment)
 	mux.HandleFunc("/expect-header", testHttpHandlerExpectHeader)
+	mux.HandleFunc("/etag", testHttpHandlerETag)
 	mux.HandleFunc("/file", testHttpHandlerFile)
 	mux.HandleFunc("/header", testHttpHandlerHeader)
 	mux.HandleFunc("/meta", testHttpHandlerMeta)
@@ -929,6 +986,12 @@ func testHttpServer(t *testing.T) net.Listener {
 	return ln
 }
 
+func testHttpHandlerAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Disposition", `attachment; filename="../hello.txt"`)
+	_, _ = w.Write([]byte("Hello\n"))
+}
+
 func testHttpHandlerExpectHeader(w http.ResponseWriter, r *http.Request) {
 	if expected, ok := r.URL.Query()["expected"]; ok {
 		if r.Header.Get(expected[0]) != "" {
@@ -944,6 +1007,11 @@ func testHttpHandlerFile(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("Hello\n"))
 }
 
+func testHttpHandlerETag(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", `"hello-etag"`)
+	_, _ = w.Write([]byte("Hello\n"))
+}
+
 func testHttpHandlerHeader(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("X-Terraform-Get", testModuleURL("basic").String())
 	w.WriteHeader(200)
@@ -1079,3 +1147,53 @@ func (m *hookableHTTPRoundTripper) RoundTrip(req *http.Request) (*http.Response,
 	}
 	return m.RoundTripper.RoundTrip(req)
 }
+
+func TestHttpGetter_insecureIsolated(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	transport := httpClient.Transport
+	dst := filepath.Join(t.TempDir(), "test-file")
+	src := fmt.Sprintf("http://%s/file", ln.Addr())
+	if err := GetFile(dst, src, WithInsecure()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, dst, "Hello\n")
+
+	if httpClient.Transport != transport {
+		t.Fatal("default HTTP client should not be modified")
+	}
+	if g := Getters["http"].(*HttpGetter); g.Client != nil {
+		t.Fatal("global getter should not be modified")
+	}
+
+	g := new(HttpGetter)
+	if g.httpClient(context.Background(), &Client{Insecure: true}) != insecureHttpClient {
+		t.Fatal("insecure client should use the insecure HTTP client")
+	}
+	if g.httpClient(context.Background(), nil) != httpClient {
+		t.Fatal("getter should default to the default HTTP client")
+	}
+}
+
+func TestHttpGetter_anyContentDisposition(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	dst := t.TempDir()
+	src := fmt.Sprintf("http://%s/attachment", ln.Addr())
+	if err := GetAny(dst, src); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "Hello\n")
+	if _, err := os.Stat(filepath.Join(dst, "attachment")); !os.IsNotExist(err) {
+		t.Fatalf("unexpected file named after the URL: %v", err)
+	}
+
+	// The filename parameter wins over the header
+	dst = t.TempDir()
+	if err := GetAny(dst, src+"?filename=foo.txt"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "foo.txt"), "Hello\n")
+}
@@ -5,7 +5,10 @@ package getter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -13,6 +16,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
@@ -33,9 +37,29 @@ type S3Getter struct {
 	Timeout time.Duration
 }
 
+// Clone implements CloneableGetter.
+func (g *S3Getter) Clone() Getter {
+	clone := *g
+	clone.client = nil
+	return &clone
+}
+
+// Pin implements Pinner.
+func (g *S3Getter) Pin(u *url.URL, version string) *url.URL {
+	pinned := *u
+	q := pinned.Query()
+	q.Set("version", version)
+	pinned.RawQuery = q.Encode()
+	return &pinned
+}
+
 func (g *S3Getter) ClientMode(u *url.URL) (ClientMode, error) {
-	// Parse URL
-	ctx := g.Context()
+	return g.ClientModeContext(g.Context(), &GetterRequest{Client: g.client, URL: u})
+}
+
+// ClientModeContext implements GetterContext.
+func (g *S3Getter) ClientModeContext(ctx context.Context, gr *GetterRequest) (ClientMode, error) {
+	u := gr.URL
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -43,13 +67,14 @@ func (g *S3Getter) ClientMode(u *url.URL) (ClientMode, error) {
 		defer cancel()
 	}
 
+	// Parse URL
 	region, bucket, path, _, creds, err := g.parseUrl(u)
 	if err != nil {
 		return 0, err
 	}
 
 	// Create client config
-	client, err := g.newS3Client(region, u, creds)
+	client, err := g.newS3Client(ctx, region, u, creds)
 	if err != nil {
 		return 0, err
 	}
@@ -63,7 +88,7 @@ func (g *S3Getter) ClientMode(u *url.URL) (ClientMode, error) {
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return 0, err
+			return 0, s3Error(err)
 		}
 
 		for _, o := range output.Contents {
@@ -85,7 +110,13 @@ func (g *S3Getter) ClientMode(u *url.URL) (ClientMode, error) {
 }
 
 func (g *S3Getter) Get(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetContext implements GetterContext.
+func (g *S3Getter) GetContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -100,24 +131,24 @@ func (g *S3Getter) Get(dst string, u *url.URL) error {
 	}
 
 	// Remove destination if it already exists
-	_, err = os.Stat(dst)
-	if err != nil && !os.IsNotExist(err) {
+	_, err = fsys.Lstat(dst)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
 
 	if err == nil {
 		// Remove the destination
-		if err := os.RemoveAll(dst); err != nil {
+		if err := fsys.RemoveAll(dst); err != nil {
 			return err
 		}
 	}
 
 	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
+	if err := fsys.MkdirAll(filepath.Dir(dst), gr.Client.mode(0755)); err != nil {
 		return err
 	}
 
-	client, err := g.newS3Client(region, u, creds)
+	client, err := g.newS3Client(ctx, region, u, creds)
 	if err != nil {
 		return err
 	}
@@ -131,7 +162,7 @@ func (g *S3Getter) Get(dst string, u *url.URL) error {
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return err
+			return s3Error(err)
 		}
 
 		for _, object := range output.Contents {
@@ -147,9 +178,12 @@ func (g *S3Getter) Get(dst string, u *url.URL) error {
 			if err != nil {
 				return err
 			}
+			if !gr.Filter.Match(filepath.ToSlash(objDst)) {
+				continue
+			}
 			objDst = filepath.Join(dst, objDst)
 
-			if err := g.getObject(ctx, client, objDst, bucket, objPath, ""); err != nil {
+			if _, err := g.getObject(ctx, gr.Client, client, fsys, objDst, bucket, objPath, ""); err != nil {
 				return err
 			}
 		}
@@ -159,7 +193,13 @@ func (g *S3Getter) Get(dst string, u *url.URL) error {
 }
 
 func (g *S3Getter) GetFile(dst string, u *url.URL) error {
-	ctx := g.Context()
+	return g.GetFileContext(g.Context(), &GetterRequest{Client: g.client, Dst: dst, URL: u})
+}
+
+// GetFileContext implements GetterContext.
+func (g *S3Getter) GetFileContext(ctx context.Context, gr *GetterRequest) error {
+	dst, u := gr.Dst, gr.URL
+	fsys := destFS(gr.FS)
 
 	if g.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -172,15 +212,84 @@ func (g *S3Getter) GetFile(dst string, u *url.URL) error {
 		return err
 	}
 
-	client, err := g.newS3Client(region, u, creds)
+	client, err := g.newS3Client(ctx, region, u, creds)
+	if err != nil {
+		return err
+	}
+
+	version, err = g.getObject(ctx, gr.Client, client, fsys, dst, bucket, path, version)
 	if err != nil {
 		return err
 	}
+	gr.Client.setVersion(version)
+	return nil
+}
+
+// OpenContext implements Opener.
+func (g *S3Getter) OpenContext(ctx context.Context, gr *GetterRequest) (io.ReadCloser, Metadata, error) {
+	// The timeout covers reading the object, so it is only cancelled once
+	// the object is closed.
+	var cancel context.CancelFunc
+	if g.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	rc, md, err := g.openObject(ctx, gr.Client, gr.URL)
+	if err != nil {
+		cancel()
+		return nil, Metadata{}, err
+	}
+	return &limitedWrappedReaderCloser{
+		underlying: rc,
+		closeFn: func() error {
+			defer cancel()
+			return rc.Close()
+		},
+	}, md, nil
+}
+
+// openObject opens the single object referenced by u for the client c.
+func (g *S3Getter) openObject(ctx context.Context, c *Client, u *url.URL) (io.ReadCloser, Metadata, error) {
+	region, bucket, path, version, creds, err := g.parseUrl(u)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	client, err := g.newS3Client(ctx, region, u, creds)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	req := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(path),
+	}
+	if version != "" {
+		req.VersionId = aws.String(version)
+	}
+
+	resp, err := client.GetObject(ctx, req)
+	if err != nil {
+		return nil, Metadata{}, s3Error(err)
+	}
 
-	return g.getObject(ctx, client, dst, bucket, path, version)
+	md := Metadata{
+		Size:        -1,
+		ContentType: aws.ToString(resp.ContentType),
+	}
+	if resp.ContentLength != nil {
+		md.Size = *resp.ContentLength
+	}
+	c.setVersion(aws.ToString(resp.VersionId))
+	return resp.Body, md, nil
 }
 
-func (g *S3Getter) getObject(ctx context.Context, client *s3.Client, dst, bucket, key, version string) error {
+// getObject downloads a single object to dst on fsys for the client c and
+// returns its VersionId, which is empty if versioning is not enabled on the
+// bucket.
+func (g *S3Getter) getObject(ctx context.Context, c *Client, client *s3.Client, fsys WritableFS, dst, bucket, key, version string) (string, error) {
 	req := &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
@@ -191,27 +300,107 @@ func (g *S3Getter) getObject(ctx context.Context, client *s3.Client, dst, bucket
 
 	resp, err := client.GetObject(ctx, req)
 	if err != nil {
-		return err
+		return "", s3Error(err)
 	}
 
 	// Create all the parent directories
-	if err := os.MkdirAll(filepath.Dir(dst), g.client.mode(0755)); err != nil {
-		return err
+	if err := fsys.MkdirAll(filepath.Dir(dst), c.mode(0755)); err != nil {
+		return "", err
 	}
 
 	body := resp.Body
 
-	if g.client != nil && g.client.ProgressListener != nil {
+	if c != nil && c.ProgressListener != nil {
 		fn := filepath.Base(key)
-		body = g.client.ProgressListener.TrackProgress(fn, 0, aws.ToInt64(resp.ContentLength), resp.Body)
+		body = c.ProgressListener.TrackProgress(fn, 0, aws.ToInt64(resp.ContentLength), resp.Body)
 	}
 	defer func() { _ = body.Close() }()
 
-	// There is no limit set for the size of an object from S3
-	return copyReader(dst, body, 0666, g.client.umask(), 0)
+	// The size of objects is bounded by the Limits of the client, which
+	// fsys enforces.
+	if err := copyReader(ctx, fsys, dst, body, 0666, c.umask(), 0); err != nil {
+		return "", s3Error(err)
+	}
+	return aws.ToString(resp.VersionId), nil
+}
+
+// CheckContext implements Checker. The state of an object is its ETag. If
+// there is no object at the path, it is the one of every object below it.
+func (g *S3Getter) CheckContext(ctx context.Context, gr *GetterRequest, previous string) (*CheckResult, error) {
+	u := gr.URL
+
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	region, bucket, path, version, creds, err := g.parseUrl(u)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := g.newS3Client(ctx, region, u, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(path),
+	}
+	if version != "" {
+		req.VersionId = aws.String(version)
+	}
+	resp, err := client.HeadObject(ctx, req)
+	if err == nil {
+		return newCheckResult(previous, aws.ToString(resp.ETag)), nil
+	}
+	if err = s3Error(err); version != "" || !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	var objects []string
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(strings.TrimSuffix(path, "/") + "/"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, s3Error(err)
+		}
+		for _, o := range output.Contents {
+			objects = append(objects, aws.ToString(o.Key)+" "+aws.ToString(o.ETag))
+		}
+	}
+	if len(objects) == 0 {
+		return nil, err
+	}
+	return newCheckResult(previous, listingState(objects)), nil
+}
+
+// s3Error maps an error of the AWS SDK onto the sentinel errors, and marks
+// it as retryable if the SDK classifies it as a retryable or throttling
+// error.
+func s3Error(err error) error {
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NoSuchBucket", "NoSuchVersion", "NotFound":
+			return withSentinel(err, ErrNotFound)
+		case "AccessDenied", "Forbidden", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "InvalidToken":
+			return withSentinel(err, ErrUnauthorized)
+		}
+	}
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary ||
+		retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return &RetryableError{Err: err}
+	}
+	return err
 }
 
-func (g *S3Getter) getAWSConfig(region string, url *url.URL, staticCreds *credentials.StaticCredentialsProvider) (conf aws.Config, err error) {
+func (g *S3Getter) getAWSConfig(ctx context.Context, region string, url *url.URL, staticCreds *credentials.StaticCredentialsProvider) (conf aws.Config, err error) {
 	var loadOptions []func(*config.LoadOptions) error
 	var creds aws.CredentialsProvider
 
@@ -238,7 +427,7 @@ func (g *S3Getter) getAWSConfig(region string, url *url.URL, staticCreds *creden
 		loadOptions = append(loadOptions, config.WithRegion(region))
 	}
 
-	return config.LoadDefaultConfig(g.Context(), loadOptions...)
+	return config.LoadDefaultConfig(ctx, loadOptions...)
 }
 
 func (g *S3Getter) parseUrl(u *url.URL) (region, bucket, path, version string, creds *credentials.StaticCredentialsProvider, err error) {
@@ -342,17 +531,17 @@ func (g *S3Getter) parseUrl(u *url.URL) (region, bucket, path, version string, c
 }
 
 func (g *S3Getter) newS3Client(
-	region string, url *url.URL, creds *credentials.StaticCredentialsProvider,
+	ctx context.Context, region string, url *url.URL, creds *credentials.StaticCredentialsProvider,
 ) (*s3.Client, error) {
 	var err error
 	var cfg aws.Config
 
 	if profile := url.Query().Get("aws_profile"); profile != "" {
-		cfg, err = config.LoadDefaultConfig(g.Context(),
+		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithSharedConfigProfile(profile),
 		)
 	} else {
-		cfg, err = g.getAWSConfig(region, url, creds)
+		cfg, err = g.getAWSConfig(ctx, region, url, creds)
 	}
 
 	if err != nil {
@@ -4,6 +4,10 @@
 package getter
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -165,6 +169,71 @@ func TestGet_archiveRooted(t *testing.T) {
 	}
 }
 
+func TestGet_archiveStrip(t *testing.T) {
+	for _, strip := range []string{"1", "auto"} {
+		dst := filepath.Join(t.TempDir(), "target")
+		u := testModule("archive-rooted/archive.tar.gz") + "?archive_strip=" + strip
+		if err := Get(dst, u); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		mainPath := filepath.Join(dst, "hello.txt")
+		if _, err := os.Stat(mainPath); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	err := Get(filepath.Join(t.TempDir(), "target"), testModule("archive-rooted/archive.tar.gz")+"?archive_strip=-1")
+	if err == nil || !strings.Contains(err.Error(), "invalid archive_strip") {
+		t.Fatalf("expected an invalid archive_strip error, got %v", err)
+	}
+}
+
+func TestGet_archiveSubdirMember(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	u := testModule("archive-rooted/archive.tar.gz") + "//root"
+	if err := Get(dst, u); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "hello\n")
+
+	// The decompressor extracted the subdir, so it isn't copied
+	if _, err := os.Stat(filepath.Join(dst, "root")); !os.IsNotExist(err) {
+		t.Fatalf("unexpected root directory: %v", err)
+	}
+
+	err := Get(filepath.Join(t.TempDir(), "target"), testModule("archive-rooted/archive.tar.gz")+"//missing")
+	if !errors.Is(err, ErrSubdirNotFound) {
+		t.Fatalf("expected ErrSubdirNotFound, got %v", err)
+	}
+}
+
+func TestGetFile_archiveMember(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "hello")
+	u := testModule("archive-rooted/archive.tar.gz") + "?archive_member=root/hello.txt"
+	if err := GetFile(dst, u); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, dst, "hello\n")
+
+	// In any mode the member is written under its name
+	dst = t.TempDir()
+	if err := GetAny(dst, u); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(dst, "hello.txt"), "hello\n")
+
+	err := GetFile(filepath.Join(t.TempDir(), "hello"), testModule("archive-rooted/archive.tar.gz")+"?archive_member=root/missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	err = GetFile(filepath.Join(t.TempDir(), "hello"), testModule("basic-file/foo.txt")+"?archive_member=foo")
+	if err == nil || !strings.Contains(err.Error(), "requires a tar or zip archive") {
+		t.Fatalf("expected an error without an archive, got %v", err)
+	}
+}
+
 func TestGet_archiveSubdirWild(t *testing.T) {
 	dst := filepath.Join(t.TempDir(), "target")
 	u := testModule("archive-rooted/archive.tar.gz")
@@ -545,3 +614,275 @@ func TestGetFile_checksumSkip(t *testing.T) {
 		t.Fatalf("get should not have been called")
 	}
 }
+
+func TestGetWithResult_dir(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client := &Client{
+		Src:  filepath.Join(".", "testdata", "basic"),
+		Dst:  dst,
+		Pwd:  pwd,
+		Mode: ClientModeAny,
+	}
+
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := result.Detector.(*FileDetector); !ok {
+		t.Fatalf("expected the file detector, got %T", result.Detector)
+	}
+	if result.Getter != "file" {
+		t.Fatalf("bad getter: %q", result.Getter)
+	}
+	if result.Mode != ClientModeDir {
+		t.Fatalf("bad mode: %d", result.Mode)
+	}
+	if result.Decompressor != "" || result.ChecksumType != "" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	if result.Files == 0 || result.Bytes == 0 {
+		t.Fatalf("expected files to be counted: %#v", result)
+	}
+}
+
+func TestGetWithResult_archiveChecksum(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "target")
+	u := testModule("basic-file-archive/archive.tar.gz")
+	u += "?checksum=md5:fbd90037dacc4b1ab40811d610dde2f0"
+
+	client := &Client{
+		Src:  u,
+		Dst:  dst,
+		Mode: ClientModeAny,
+	}
+
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if result.Detector != nil {
+		t.Fatalf("expected no detector, got %T", result.Detector)
+	}
+	if result.Decompressor != "tar.gz" {
+		t.Fatalf("bad decompressor: %q", result.Decompressor)
+	}
+	if result.ChecksumType != "md5" || !result.ChecksumVerified || result.ChecksumSkipped {
+		t.Fatalf("bad checksum result: %#v", result)
+	}
+	if result.Mode != ClientModeDir {
+		t.Fatalf("bad mode: %d", result.Mode)
+	}
+	if result.Files != 1 {
+		t.Fatalf("expected 1 file, got %d", result.Files)
+	}
+}
+
+func TestGetWithResult_checksumSkip(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "test-file")
+	u := testModule("basic-file/foo.txt") + "?checksum=md5:09f7e02f1290be211da707a266f153b3"
+
+	client := &Client{
+		Src:  u,
+		Dst:  dst,
+		Mode: ClientModeFile,
+	}
+
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !result.ChecksumVerified || result.ChecksumSkipped {
+		t.Fatalf("bad checksum result: %#v", result)
+	}
+
+	result, err = client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.ChecksumVerified || !result.ChecksumSkipped {
+		t.Fatalf("bad checksum result: %#v", result)
+	}
+	if result.Mode != ClientModeFile || result.Files != 1 || result.Bytes != 6 {
+		t.Fatalf("bad result: %#v", result)
+	}
+}
+
+// contextGetter is a MockGetter that implements GetterContext, recording
+// the context and request it was called with.
+type contextGetter struct {
+	MockGetter
+
+	ctx context.Context
+	req *GetterRequest
+}
+
+func (g *contextGetter) GetContext(ctx context.Context, req *GetterRequest) error {
+	g.ctx, g.req = ctx, req
+	return ctx.Err()
+}
+
+func (g *contextGetter) GetFileContext(ctx context.Context, req *GetterRequest) error {
+	g.ctx, g.req = ctx, req
+	return ctx.Err()
+}
+
+func (g *contextGetter) ClientModeContext(ctx context.Context, req *GetterRequest) (ClientMode, error) {
+	return ClientModeFile, nil
+}
+
+func TestGet_getterContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	g := new(contextGetter)
+	dst := filepath.Join(t.TempDir(), "file")
+	client := &Client{
+		Ctx:     ctx,
+		Src:     "mock://example.com/file",
+		Dst:     dst,
+		Mode:    ClientModeAny,
+		Getters: map[string]Getter{"mock": g},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if g.GetFileCalled {
+		t.Fatal("the legacy GetFile should not be called")
+	}
+	if g.ctx == nil || g.ctx.Value(ctxKey{}) != "value" {
+		t.Fatal("the client context was not passed to GetFileContext")
+	}
+	if g.req.Client != client || g.req.Dst != filepath.Join(dst, "file") || g.req.URL.String() != "mock://example.com/file" {
+		t.Fatalf("bad request: %#v", g.req)
+	}
+}
+
+func TestGet_getterContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := new(contextGetter)
+	client := &Client{
+		Ctx:     ctx,
+		Src:     "mock://example.com/file",
+		Dst:     filepath.Join(t.TempDir(), "file"),
+		Mode:    ClientModeFile,
+		Getters: map[string]Getter{"mock": g},
+	}
+	if err := client.Get(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGetterContext_legacy(t *testing.T) {
+	g := new(MockGetter)
+	u, err := url.Parse("mock://example.com/dir/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	gc := getterContext(g)
+	if err := gc.GetContext(context.Background(), &GetterRequest{Dst: "dst", URL: u}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !g.GetCalled || g.GetDst != "dst" || g.GetURL != u {
+		t.Fatal("Get should be called through the adapter")
+	}
+
+	mode, err := gc.ClientModeContext(context.Background(), &GetterRequest{URL: u})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if mode != ClientModeDir {
+		t.Fatalf("bad mode: %d", mode)
+	}
+}
+
+func TestClient_getterIsolation(t *testing.T) {
+	var clients [2]*Client
+	for i := range clients {
+		clients[i] = &Client{}
+		if err := clients[i].Configure(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	for _, c := range clients {
+		hg, ok := c.getters["http"].(*HttpGetter)
+		if !ok {
+			t.Fatalf("bad getter: %T", c.getters["http"])
+		}
+		if hg == Getters["http"] {
+			t.Fatal("client should use its own copy of the http getter")
+		}
+		if hg != c.getters["https"] {
+			t.Fatal("http and https should share the same copy")
+		}
+		if hg.client != c {
+			t.Fatal("getter should be set up for its own client")
+		}
+		if !hg.Netrc {
+			t.Fatal("copy should keep the settings of the getter")
+		}
+	}
+	if clients[0].getters["git"] == clients[1].getters["git"] {
+		t.Fatal("clients should not share getters")
+	}
+	if hg := Getters["http"].(*HttpGetter); hg.client == clients[0] || hg.client == clients[1] {
+		t.Fatal("global getters should not be set up for a client")
+	}
+
+	// Getters that can't be cloned are shared, and the map of the caller
+	// is left as it is.
+	g := new(MockGetter)
+	hg := new(HttpGetter)
+	getters := map[string]Getter{"mock": g, "http": hg}
+	c := &Client{Getters: getters}
+	if err := c.Configure(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.getters["mock"] != g {
+		t.Fatal("mock getter should be shared")
+	}
+	if c.Getters["http"] != hg || getters["http"] != hg {
+		t.Fatal("the getters of the caller should be left untouched")
+	}
+	if hg.client != nil {
+		t.Fatal("the getter of the caller should not be set up for the client")
+	}
+}
+
+func TestClient_concurrentOptions(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	const n = 8
+	trackers := make([]*MockProgressTracking, n)
+	errs := make(chan error, n)
+	for i := range trackers {
+		trackers[i] = &MockProgressTracking{}
+		go func(p *MockProgressTracking) {
+			dst := filepath.Join(t.TempDir(), "file")
+			errs <- GetFile(dst, fmt.Sprintf("http://%s/file", ln.Addr()), WithProgress(p))
+		}(trackers[i])
+	}
+	for range trackers {
+		if err := <-errs; err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	for i, p := range trackers {
+		if p.downloaded["file"] != 1 {
+			t.Fatalf("tracker %d: expected one download, got %d", i, p.downloaded["file"])
+		}
+	}
+}
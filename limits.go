@@ -0,0 +1,271 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Limits bounds what a single download may write. They apply to the
+// destination and to every temporary copy of the source, like an archive
+// before it is unpacked, and are enforced by all getters and decompressors.
+// Zero values mean no limit.
+type Limits struct {
+	// MaxTotalBytes is the maximum total size of the files written.
+	MaxTotalBytes int64
+
+	// MaxFiles is the maximum number of files written.
+	MaxFiles int
+
+	// MaxFileSize is the maximum size of any single file.
+	MaxFileSize int64
+
+	// SkipVCSMetadata excludes the .git and .hg directories of git and
+	// Mercurial clones from the limits. Their size is up to the remote,
+	// so only set it for repositories that are trusted.
+	SkipVCSMetadata bool
+}
+
+// enabled reports whether any limit is set.
+func (l Limits) enabled() bool {
+	return l.MaxTotalBytes > 0 || l.MaxFiles > 0 || l.MaxFileSize > 0
+}
+
+// LimitError is returned when a download exceeds one of the Limits of
+// the client.
+type LimitError struct {
+	// Limit is the name of the field of Limits that was exceeded, such as
+	// "MaxFileSize".
+	Limit string
+
+	// Max is the value of that limit.
+	Max int64
+
+	// Path is the file that exceeded the limit.
+	Path string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("download exceeds the %s limit of %d at %s", e.Limit, e.Max, e.Path)
+}
+
+// quota tracks what a download wrote against its Limits.
+type quota struct {
+	limits Limits
+
+	mu    sync.Mutex
+	files int
+	bytes int64
+}
+
+// addFile accounts for a new file at path.
+func (q *quota) addFile(path string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limits.MaxFiles > 0 && q.files >= q.limits.MaxFiles {
+		return &LimitError{Limit: "MaxFiles", Max: int64(q.limits.MaxFiles), Path: path}
+	}
+	q.files++
+	return nil
+}
+
+// addBytes accounts for a file at path growing by n bytes to size.
+func (q *quota) addBytes(path string, n, size int64) error {
+	if q.limits.MaxFileSize > 0 && size > q.limits.MaxFileSize {
+		return &LimitError{Limit: "MaxFileSize", Max: q.limits.MaxFileSize, Path: path}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limits.MaxTotalBytes > 0 && q.bytes+n > q.limits.MaxTotalBytes {
+		return &LimitError{Limit: "MaxTotalBytes", Max: q.limits.MaxTotalBytes, Path: path}
+	}
+	q.bytes += n
+	return nil
+}
+
+// limitFS is a WritableFS that fails writes exceeding a quota.
+type limitFS struct {
+	WritableFS
+	quota *quota
+}
+
+// limitedFS returns fsys enforcing the Limits of the client, with a quota
+// of its own. It returns fsys as is if there are no limits.
+func (c *Client) limitedFS(fsys WritableFS) WritableFS {
+	if c == nil || !c.Limits.enabled() {
+		return fsys
+	}
+	return &limitFS{WritableFS: fsys, quota: &quota{limits: c.Limits}}
+}
+
+func (fsys *limitFS) OpenFile(name string, flag int, perm os.FileMode) (WritableFile, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return fsys.WritableFS.OpenFile(name, flag, perm)
+	}
+
+	var size int64
+	fi, err := fsys.Lstat(name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := fsys.quota.addFile(name); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case flag&os.O_TRUNC == 0:
+		size = fi.Size()
+	}
+
+	f, err := fsys.WritableFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &limitFile{WritableFile: f, name: name, quota: fsys.quota, size: size}, nil
+}
+
+// Link implements LinkFS if the filesystem it wraps does. A hard link
+// counts as a file, but its bytes were already accounted for.
+func (fsys *limitFS) Link(oldname, newname string) error {
+	lfs, ok := fsys.WritableFS.(LinkFS)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	if err := fsys.quota.addFile(newname); err != nil {
+		return err
+	}
+	return lfs.Link(oldname, newname)
+}
+
+// withNewQuota returns fsys with a quota of its own if it enforces limits,
+// for another attempt at writing the same content.
+func withNewQuota(fsys WritableFS) WritableFS {
+	if lfs, ok := fsys.(*limitFS); ok {
+		return &limitFS{WritableFS: lfs.WritableFS, quota: &quota{limits: lfs.quota.limits}}
+	}
+	return fsys
+}
+
+// limitFile is a file opened for writing by limitFS.
+type limitFile struct {
+	WritableFile
+	name   string
+	quota  *quota
+	offset int64
+	size   int64
+}
+
+func (f *limitFile) Write(p []byte) (int, error) {
+	if end := f.offset + int64(len(p)); end > f.size {
+		if err := f.quota.addBytes(f.name, end-f.size, end); err != nil {
+			return 0, err
+		}
+		f.size = end
+	}
+	n, err := f.WritableFile.Write(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *limitFile) Seek(offset int64, whence int) (int64, error) {
+	off, err := f.WritableFile.Seek(offset, whence)
+	if err == nil {
+		f.offset = off
+	}
+	return off, err
+}
+
+// limitsCheckInterval is how often watchLimits checks what is written.
+var limitsCheckInterval = 250 * time.Millisecond
+
+// vcsDirs are the names of the metadata directories of version control
+// systems, which Limits.SkipVCSMetadata excludes.
+var vcsDirs = map[string]bool{".git": true, ".hg": true}
+
+// watchLimits runs fn, which writes to path on the OS filesystem without
+// going through a WritableFS, like a git clone. While fn runs, what it
+// wrote is checked against the Limits of the client every
+// limitsCheckInterval, and the context passed to fn is cancelled as soon
+// as a limit is exceeded. It returns the *LimitError in that case, and
+// also checks what fn wrote once it returned.
+func (c *Client) watchLimits(ctx context.Context, path string, fn func(context.Context) error) error {
+	if c == nil || !c.Limits.enabled() {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(limitsCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			// Files may disappear while they are written, so only limit
+			// errors stop the download.
+			var lerr *LimitError
+			if err := c.checkLimits(path); errors.As(err, &lerr) {
+				cancel(err)
+				return
+			}
+		}
+	}()
+
+	err := fn(ctx)
+	close(done)
+	<-stopped
+
+	var lerr *LimitError
+	if errors.As(context.Cause(ctx), &lerr) {
+		return lerr
+	}
+	if err != nil {
+		return err
+	}
+	return c.checkLimits(path)
+}
+
+// checkLimits walks what a getter wrote to path on the OS filesystem
+// without going through a WritableFS, such as a git clone, and returns a
+// *LimitError if it exceeds the Limits of the client. The metadata
+// directories of version control systems are only skipped if
+// Limits.SkipVCSMetadata is set.
+func (c *Client) checkLimits(path string) error {
+	if c == nil || !c.Limits.enabled() {
+		return nil
+	}
+
+	q := &quota{limits: c.Limits}
+	err := walkFS(OSFS{}, path, func(p string, fi os.FileInfo) error {
+		if fi.IsDir() && c.Limits.SkipVCSMetadata && vcsDirs[fi.Name()] {
+			return filepath.SkipDir
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		if err := q.addFile(p); err != nil {
+			return err
+		}
+		return q.addBytes(p, fi.Size(), fi.Size())
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testLimitError(t *testing.T, err error, limit string) {
+	t.Helper()
+
+	var lerr *LimitError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("expected a limit error, got %v", err)
+	}
+	if lerr.Limit != limit {
+		t.Fatalf("expected %s to be exceeded, got %s", limit, lerr.Limit)
+	}
+}
+
+func TestWithLimits_http(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	client := &Client{
+		Src:     fmt.Sprintf("http://%s/file", ln.Addr()),
+		Dst:     filepath.Join(t.TempDir(), "file"),
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithLimits(Limits{MaxFileSize: 3})},
+	}
+	testLimitError(t, client.Get(), "MaxFileSize")
+
+	client.Options = []ClientOption{WithLimits(Limits{MaxFileSize: 6, MaxTotalBytes: 6, MaxFiles: 1})}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, client.Dst, "Hello\n")
+}
+
+func TestWithLimits_archive(t *testing.T) {
+	src := testModule("decompress-tgz/multiple_dir.tar.gz")
+	client := &Client{
+		Src:     src,
+		Dst:     filepath.Join(t.TempDir(), "target"),
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithLimits(Limits{MaxFiles: 1})},
+	}
+	testLimitError(t, client.Get(), "MaxFiles")
+
+	client.Options = []ClientOption{WithLimits(Limits{MaxTotalBytes: 10})}
+	testLimitError(t, client.Get(), "MaxTotalBytes")
+
+	client.Options = []ClientOption{WithLimits(Limits{MaxFiles: 2, MaxTotalBytes: 12})}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestWithLimits_subdir(t *testing.T) {
+	client := &Client{
+		Src:     testModule("basic") + "//subdir",
+		Dst:     filepath.Join(t.TempDir(), "target"),
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithLimits(Limits{MaxTotalBytes: 50})},
+	}
+	testLimitError(t, client.Get(), "MaxTotalBytes")
+}
+
+func TestWithLimits_git(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "limits")
+	repo.commitFile("foo.txt", "hello")
+	repo.commitFile("bar.txt", "world")
+
+	client := &Client{
+		Src:     "git::" + repo.url.String(),
+		Dst:     filepath.Join(t.TempDir(), "target"),
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithLimits(Limits{MaxFiles: 1})},
+	}
+	testLimitError(t, client.Get(), "MaxFiles")
+
+	// The .git directory counts towards the limits, unless it is skipped.
+	client.Dst = filepath.Join(t.TempDir(), "target")
+	client.Options = []ClientOption{WithLimits(Limits{MaxFiles: 2})}
+	testLimitError(t, client.Get(), "MaxFiles")
+
+	client.Dst = filepath.Join(t.TempDir(), "target")
+	client.Options = []ClientOption{WithLimits(Limits{MaxFiles: 2, MaxTotalBytes: 10, SkipVCSMetadata: true})}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertContents(t, filepath.Join(client.Dst, "bar.txt"), "world")
+}
+
+func TestClient_watchLimits(t *testing.T) {
+	defer func(d time.Duration) { limitsCheckInterval = d }(limitsCheckInterval)
+	limitsCheckInterval = 10 * time.Millisecond
+
+	dst := filepath.Join(t.TempDir(), "target")
+	c := &Client{Limits: Limits{MaxFiles: 3}}
+
+	// The download is stopped while it runs, not once it is done.
+	var written int
+	err := c.watchLimits(context.Background(), dst, func(ctx context.Context) error {
+		if err := os.MkdirAll(filepath.Join(dst, ".git"), 0755); err != nil {
+			return err
+		}
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+			name := filepath.Join(dst, fmt.Sprintf("%d.txt", i))
+			if i%2 == 0 {
+				name = filepath.Join(dst, ".git", fmt.Sprintf("%d", i))
+			}
+			if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+				return err
+			}
+			written++
+			if written > 10000 {
+				return errors.New("download was not stopped")
+			}
+		}
+	})
+	testLimitError(t, err, "MaxFiles")
+}
@@ -0,0 +1,192 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Lockfile records the immutable version every source was downloaded at,
+// so later downloads can reproduce them exactly. It is keyed by the source
+// string as it was passed to the client, and is safe for concurrent use by
+// the clients of a GetAll batch.
+//
+// A Lockfile is stored as JSON with ReadLockfile and WriteFile.
+type Lockfile struct {
+	Sources map[string]LockedSource `json:"sources"`
+
+	mu sync.Mutex
+}
+
+// LockedSource is the entry of a source in a Lockfile.
+type LockedSource struct {
+	// Getter is the key in Client.Getters of the getter that downloaded
+	// the source.
+	Getter string `json:"getter"`
+
+	// URL is the URL that was passed to the getter, with any credentials
+	// redacted. It is informational only.
+	URL string `json:"url"`
+
+	// Version is the GetResult.Version the source resolved to: the commit
+	// SHA for git, the changeset for Mercurial, the VersionId for S3, the
+	// generation for GCS and the ETag for HTTP.
+	Version string `json:"version,omitempty"`
+
+	// SHA256 is the hex encoded sha256 of the downloaded file, before it
+	// was decompressed. It is empty for directory downloads.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Pinner is implemented by getters that can download again the exact
+// version they reported in GetResult.Version.
+type Pinner interface {
+	// Pin returns a copy of u that references version.
+	Pin(u *url.URL, version string) *url.URL
+}
+
+// NewLockfile returns an empty Lockfile.
+func NewLockfile() *Lockfile {
+	return &Lockfile{Sources: make(map[string]LockedSource)}
+}
+
+// ReadLockfile reads a Lockfile written by WriteFile.
+func ReadLockfile(path string) (*Lockfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewLockfile()
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("error parsing lockfile %s: %w", path, err)
+	}
+	if l.Sources == nil {
+		l.Sources = make(map[string]LockedSource)
+	}
+	return l, nil
+}
+
+// WriteFile writes the Lockfile to path as indented JSON, with the sources
+// sorted so the file diffs well.
+func (l *Lockfile) WriteFile(path string) error {
+	l.mu.Lock()
+	b, err := json.MarshalIndent(l, "", "  ")
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Get returns the entry of src.
+func (l *Lockfile) Get(src string) (LockedSource, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ls, ok := l.Sources[src]
+	return ls, ok
+}
+
+// Set replaces the entry of src.
+func (l *Lockfile) Set(src string, ls LockedSource) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Sources == nil {
+		l.Sources = make(map[string]LockedSource)
+	}
+	l.Sources[src] = ls
+}
+
+// pinSource rewrites rs to the version pinned for the source in a frozen
+// lockfile, and returns the entry it was pinned to. It returns nil if the
+// lockfile is not frozen.
+func (c *Client) pinSource(rs *resolvedSource) (*LockedSource, error) {
+	if c.Lockfile == nil || !c.FrozenLockfile {
+		return nil, nil
+	}
+
+	ls, ok := c.Lockfile.Get(c.Src)
+	if !ok {
+		return nil, fmt.Errorf("source %q is not in the lockfile", redactSource(c.Src))
+	}
+	if ls.Getter != rs.getterKey {
+		return nil, fmt.Errorf("source %q is locked to getter %q, but resolved to %q",
+			redactSource(c.Src), ls.Getter, rs.getterKey)
+	}
+
+	if p, ok := rs.getter.(Pinner); ok && ls.Version != "" {
+		rs.u = p.Pin(rs.u, ls.Version)
+	} else {
+		// The version can't be requested, so it is not checked either.
+		ls.Version = ""
+	}
+
+	// An explicit checksum already guards the content.
+	if ls.SHA256 != "" && rs.checksum == nil && rs.checksumFile == "" {
+		checksum, err := newChecksumFromType("sha256", ls.SHA256, filepath.Base(rs.u.Path))
+		if err != nil {
+			return nil, fmt.Errorf("invalid lockfile entry for %q: %w", redactSource(c.Src), err)
+		}
+		rs.checksum = checksum
+	}
+
+	return &ls, nil
+}
+
+// checkPin returns an error if the getter resolved a different version
+// than the one the source was pinned to.
+func checkPin(pin *LockedSource, result *GetResult) error {
+	if pin == nil || pin.Version == "" || pin.Version == result.Version {
+		return nil
+	}
+	return fmt.Errorf("%s resolved to version %q, but the lockfile pins %q",
+		result.URL, result.Version, pin.Version)
+}
+
+// lockResult records result in the lockfile of the client, unless it is
+// frozen.
+func (c *Client) lockResult(result *GetResult) {
+	if c.Lockfile == nil || c.FrozenLockfile {
+		return
+	}
+
+	ls := LockedSource{
+		Getter:  result.Getter,
+		URL:     result.URL,
+		Version: result.Version,
+		SHA256:  result.SHA256,
+	}
+
+	// The getter did not run if the destination already matched the
+	// checksum, so keep the version recorded for that same content.
+	if old, ok := c.Lockfile.Get(c.Src); ok && result.ChecksumSkipped && ls.Version == "" &&
+		old.SHA256 == ls.SHA256 {
+		ls.Version = old.Version
+	}
+
+	c.Lockfile.Set(c.Src, ls)
+}
+
+// fileSHA256 returns the hex encoded sha256 of the file at path on fsys.
+func fileSHA256(fsys WritableFS, path string) (string, error) {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
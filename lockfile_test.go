@@ -0,0 +1,185 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLockfile_git(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not found, skipping")
+	}
+
+	repo := testGitRepo(t, "lockfile")
+	repo.commitFile("foo.txt", "hello")
+	commit, err := repo.latestCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "git::" + repo.url.String()
+	l := NewLockfile()
+	client := &Client{
+		Src:     src,
+		Dst:     filepath.Join(t.TempDir(), "target"),
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithLockfile(l)},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ls, ok := l.Get(src)
+	if !ok {
+		t.Fatal("source should be locked")
+	}
+	if ls.Getter != "git" || ls.Version != commit || ls.SHA256 != "" {
+		t.Fatalf("bad entry: %#v", ls)
+	}
+
+	// A new commit is ignored by a frozen download
+	repo.commitFile("bar.txt", "world")
+
+	dst := filepath.Join(t.TempDir(), "target")
+	client = &Client{
+		Src:     src,
+		Dst:     dst,
+		Mode:    ClientModeDir,
+		Options: []ClientOption{WithFrozenLockfile(l)},
+	}
+	result, err := client.GetWithResult()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Version != commit {
+		t.Fatalf("expected version %q, got %q", commit, result.Version)
+	}
+	assertContents(t, filepath.Join(dst, "foo.txt"), "hello")
+	if _, err := os.Stat(filepath.Join(dst, "bar.txt")); !os.IsNotExist(err) {
+		t.Fatalf("bar.txt should not exist: %v", err)
+	}
+}
+
+func TestLockfile_http(t *testing.T) {
+	ln := testHttpServer(t)
+	defer func() { _ = ln.Close() }()
+
+	src := fmt.Sprintf("http://%s/etag", ln.Addr())
+	l := NewLockfile()
+	client := &Client{
+		Src:     src,
+		Dst:     filepath.Join(t.TempDir(), "file"),
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithLockfile(l)},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := LockedSource{
+		Getter:  "http",
+		URL:     src,
+		Version: `"hello-etag"`,
+		SHA256:  "66a045b452102c59d840ec097d59d9467e13a3f34f6494e539ffd32c1bb35f18",
+	}
+	if ls, _ := l.Get(src); ls != expected {
+		t.Fatalf("bad entry: %#v", ls)
+	}
+
+	client = &Client{
+		Src:     src,
+		Dst:     filepath.Join(t.TempDir(), "file"),
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithFrozenLockfile(l)},
+	}
+	if err := client.Get(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Content that changed since it was locked is rejected, and leaves
+	// the destination as it was.
+	expected.SHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+	l.Set(src, expected)
+	dst := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dst, []byte("previous"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client = &Client{
+		Src:     src,
+		Dst:     dst,
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithFrozenLockfile(l)},
+	}
+	var cerr *ChecksumError
+	if err := client.Get(); !errors.As(err, &cerr) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	assertContents(t, dst, "previous")
+}
+
+func TestLockfile_frozenNotLocked(t *testing.T) {
+	client := &Client{
+		Src:     testModule("basic-file/foo.txt"),
+		Dst:     filepath.Join(t.TempDir(), "file"),
+		Mode:    ClientModeFile,
+		Options: []ClientOption{WithFrozenLockfile(NewLockfile())},
+	}
+	if err := client.Get(); err == nil {
+		t.Fatal("expected an error for a source that is not locked")
+	}
+}
+
+func TestLockfile_readWrite(t *testing.T) {
+	l := NewLockfile()
+	l.Set("git::https://example.com/repo.git", LockedSource{
+		Getter:  "git",
+		URL:     "https://example.com/repo.git",
+		Version: "0123456789abcdef0123456789abcdef01234567",
+	})
+
+	path := filepath.Join(t.TempDir(), "getter.lock.json")
+	if err := l.WriteFile(path); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	actual, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(actual.Sources, l.Sources) {
+		t.Fatalf("bad sources: %#v", actual.Sources)
+	}
+}
+
+func TestPinner(t *testing.T) {
+	cases := []struct {
+		Getter   Pinner
+		URL      string
+		Expected string
+	}{
+		{new(GitGetter), "https://example.com/repo.git?depth=1&ref=main", "https://example.com/repo.git?ref=abc"},
+		{new(HgGetter), "https://example.com/repo", "https://example.com/repo?rev=abc"},
+		{new(S3Getter), "https://s3.amazonaws.com/bucket/foo", "https://s3.amazonaws.com/bucket/foo?version=abc"},
+		{new(GCSGetter), "https://www.googleapis.com/storage/v1/bucket/foo#123", "https://www.googleapis.com/storage/v1/bucket/foo#abc"},
+	}
+
+	for _, tc := range cases {
+		u, err := url.Parse(tc.URL)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if actual := tc.Getter.Pin(u, "abc").String(); actual != tc.Expected {
+			t.Fatalf("%T: expected %q, got %q", tc.Getter, tc.Expected, actual)
+		}
+		if u.String() != tc.URL {
+			t.Fatalf("%T: the URL should not be modified: %s", tc.Getter, u)
+		}
+	}
+}
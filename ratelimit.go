@@ -0,0 +1,92 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter limits the bandwidth of downloads, in bytes. It is
+// implemented by *rate.Limiter of golang.org/x/time/rate.
+type RateLimiter interface {
+	// WaitN blocks until n bytes may be read, or ctx is done.
+	WaitN(ctx context.Context, n int) error
+
+	// Burst is the largest number of bytes WaitN allows at once.
+	Burst() int
+}
+
+// NewRateLimiter returns a RateLimiter that allows bytesPerSecond on
+// average, with bursts of up to burst bytes. If burst is not positive, it
+// is one second worth of bytes. bytesPerSecond must be positive.
+//
+// Passing the same RateLimiter to WithRateLimiter for several clients
+// makes them share the bandwidth.
+func NewRateLimiter(bytesPerSecond int64, burst int) (RateLimiter, error) {
+	if bytesPerSecond <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %d bytes per second", bytesPerSecond)
+	}
+	if burst <= 0 {
+		burst = int(min(max(bytesPerSecond, 1), int64(^uint32(0)>>1)))
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst), nil
+}
+
+// withRateLimiter returns ctx carrying l, which Copy then honors. A nil l
+// disables rate limiting for ctx.
+func withRateLimiter(ctx context.Context, l RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterValue, l)
+}
+
+func rateLimiterFromContext(ctx context.Context) RateLimiter {
+	l, _ := ctx.Value(rateLimiterValue).(RateLimiter)
+	return l
+}
+
+// requestContext returns the context to pass to getters, which is the Ctx
+// of the client carrying its RateLimiter. It is derived on each call
+// rather than stored, so the Ctx of the client stays as it was given.
+func (c *Client) requestContext() context.Context {
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.RateLimiter == nil {
+		return ctx
+	}
+	return withRateLimiter(ctx, c.RateLimiter)
+}
+
+// readRateLimited reads from r into p, reading no more than l allows at
+// once, and waits until l allows what was read. If l has no burst, like
+// an unlimited rate.Limiter, p is read whole and l decides whether that
+// is allowed.
+func readRateLimited(ctx context.Context, l RateLimiter, r io.Reader, p []byte) (int, error) {
+	if b := l.Burst(); b > 0 && len(p) > b {
+		p = p[:b]
+	}
+	n, err := r.Read(p)
+	if n > 0 {
+		if werr := l.WaitN(ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// rateLimitedReader is a reader limited by a RateLimiter, for content that
+// is not read through Copy.
+type rateLimitedReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter RateLimiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	return readRateLimited(r.ctx, r.limiter, r.ReadCloser, p)
+}
@@ -0,0 +1,158 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// countingLimiter is a RateLimiter that counts the bytes it allows.
+type countingLimiter struct {
+	mu    sync.Mutex
+	bytes int
+}
+
+func (l *countingLimiter) WaitN(_ context.Context, n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bytes += n
+	return nil
+}
+
+func (l *countingLimiter) Burst() int { return 4 }
+
+func testRateLimiter(t *testing.T, bytesPerSecond int64, burst int) RateLimiter {
+	t.Helper()
+
+	l, err := NewRateLimiter(bytesPerSecond, burst)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return l
+}
+
+func TestNewRateLimiter_invalid(t *testing.T) {
+	for _, bps := range []int64{0, -1} {
+		if _, err := NewRateLimiter(bps, 0); err == nil {
+			t.Fatalf("expected an error for %d bytes per second", bps)
+		}
+		if err := new(Client).Configure(WithRateLimit(bps, 0)); err == nil {
+			t.Fatalf("expected WithRateLimit to fail for %d bytes per second", bps)
+		}
+	}
+}
+
+func TestCopy_rateLimit(t *testing.T) {
+	ctx := withRateLimiter(context.Background(), testRateLimiter(t, 1000, 100))
+
+	var dst bytes.Buffer
+	start := time.Now()
+	n, err := Copy(ctx, &dst, strings.NewReader(strings.Repeat("x", 300)))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n != 300 {
+		t.Fatalf("bad count: %d", n)
+	}
+
+	// The burst is free, the rest takes 200ms.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("copy was not rate limited: %s", elapsed)
+	}
+}
+
+func TestCopy_rateLimitCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx = withRateLimiter(ctx, testRateLimiter(t, 1, 1))
+
+	var dst bytes.Buffer
+	if _, err := Copy(ctx, &dst, strings.NewReader("hello")); err == nil {
+		t.Fatal("expected an error for a cancelled context")
+	}
+}
+
+func TestCopy_rateLimitNoBurst(t *testing.T) {
+	// An unlimited rate.Limiter has no burst, which must not stop reads.
+	ctx := withRateLimiter(context.Background(), rate.NewLimiter(rate.Inf, 0))
+
+	var dst bytes.Buffer
+	n, err := Copy(ctx, &dst, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n != 5 || dst.String() != "hello" {
+		t.Fatalf("bad copy: %d %q", n, dst.String())
+	}
+}
+
+func TestClient_Configure_rateLimiter(t *testing.T) {
+	// The limiter is attached to each request, not to the client context,
+	// so configuring again leaves Ctx as it was.
+	ctx := context.Background()
+	c := &Client{Ctx: ctx}
+	for range 2 {
+		if err := c.Configure(WithRateLimiter(&countingLimiter{})); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if c.Ctx != ctx {
+			t.Fatal("the client context should be left unchanged")
+		}
+	}
+	if rateLimiterFromContext(c.requestContext()) != c.RateLimiter {
+		t.Fatal("the rate limiter should be attached to the request context")
+	}
+}
+
+func TestWithRateLimiter(t *testing.T) {
+	src := testModule("decompress-gz/single.gz")
+	fi, err := os.Stat(filepath.Join(fixtureDir, "decompress-gz", "single.gz"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Clients sharing a limiter share its budget. Only the download is
+	// limited, not the decompression.
+	l := &countingLimiter{}
+	for range 2 {
+		client := &Client{
+			Src:     src,
+			Dst:     filepath.Join(t.TempDir(), "file"),
+			Mode:    ClientModeFile,
+			Getters: map[string]Getter{"file": &FileGetter{Copy: true}},
+			Options: []ClientOption{WithRateLimiter(l)},
+		}
+		if err := client.Get(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		assertContents(t, client.Dst, "foo\n")
+	}
+	if l.bytes != 2*int(fi.Size()) {
+		t.Fatalf("expected %d bytes to be limited, got %d", 2*fi.Size(), l.bytes)
+	}
+}
+
+func TestClient_Open_rateLimit(t *testing.T) {
+	l := &countingLimiter{}
+	c := &Client{Options: []ClientOption{WithRateLimiter(l)}}
+	content, _, err := testOpen(t, c, testModule("basic-file/foo.txt"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if content != "Hello\n" {
+		t.Fatalf("bad content: %q", content)
+	}
+	if l.bytes != 6 {
+		t.Fatalf("expected 6 bytes to be limited, got %d", l.bytes)
+	}
+}
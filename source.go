@@ -5,18 +5,243 @@ package getter
 
 import (
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 )
 
+// Source is a parsed source string. It separates the syntax that go-getter
+// layers on top of a URL, the forced getter, the subdirectory and the magic
+// query parameters, from the URL that is handed to a getter:
+//
+//	git::https://example.com/repo.git//modules/foo?ref=v1.0.0
+//	^^^  ^^^^^^^^^^^^^^^^^^^^^^^^^^^^^ ^^^^^^^^^^^ ^^^^^^^^^^
+//	Getter          URL                  Subdir     Params
+//
+// Tools that rewrite sources, for instance to mirror them or to pin a ref,
+// can edit the fields and call String to get the new source string.
+type Source struct {
+	// Getter is the forced getter, such as "git" in "git::https://...".
+	// It is empty if no getter is forced.
+	Getter string
+
+	// URL is the source without the forced getter, subdirectory, query
+	// and fragment.
+	URL string
+
+	// Subdir is the subdirectory to copy out of the download. It may
+	// contain glob patterns.
+	Subdir string
+
+	// Archive, Checksum and Filename are the "archive", "checksum" and
+	// "filename" query parameters, which are interpreted by the Client
+	// rather than passed on to the getter.
+	Archive  string
+	Checksum string
+	Filename string
+
+	// ArchiveStrip is the "archive_strip" query parameter, the number of
+	// leading directories to strip from the entries of an archive, or
+	// "auto" to unwrap its single root directory.
+	ArchiveStrip string
+
+	// ArchiveMember is the "archive_member" query parameter, the path of
+	// the only file or directory to extract from an archive.
+	ArchiveMember string
+
+	// Include and Exclude are the "include" and "exclude" query
+	// parameters, which may be repeated. They are glob patterns that
+	// select the files of a directory download; see Filter.
+	Include []string
+	Exclude []string
+
+	// Params are the remaining query parameters. They are passed on to
+	// the getter in its URL, such as "ref" for git or "version" for S3,
+	// and the getter reads them from there.
+	Params url.Values
+
+	// Fragment is the fragment of the URL, which the GCS getter uses to
+	// select an object generation.
+	Fragment string
+
+	// rawQuery is the query the source was parsed from, which String keeps
+	// as written as long as the fields still match it.
+	rawQuery string
+}
+
+// ParseSource parses a source string into a Source. The source string does
+// not need to be a valid URL; detection is not run.
+func ParseSource(src string) (*Source, error) {
+	s := new(Source)
+	s.Getter, src = getForcedGetter(src)
+
+	// As with url.Parse, the first "#" starts the fragment.
+	src, s.Fragment, _ = strings.Cut(src, "#")
+	src, s.Subdir = SourceDirSubdir(src)
+
+	// The query may itself contain URLs, such as checksum files, so only
+	// the first "?" starts it.
+	src, query, _ := strings.Cut(src, "?")
+	s.URL = src
+
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source query: %w", err)
+	}
+	s.rawQuery = query
+	s.Archive = q.Get("archive")
+	s.Checksum = q.Get("checksum")
+	s.Filename = q.Get("filename")
+	s.ArchiveStrip = q.Get("archive_strip")
+	s.ArchiveMember = q.Get("archive_member")
+	s.Include = q["include"]
+	s.Exclude = q["exclude"]
+	q.Del("archive")
+	q.Del("checksum")
+	q.Del("filename")
+	q.Del("archive_strip")
+	q.Del("archive_member")
+	q.Del("include")
+	q.Del("exclude")
+	if len(q) > 0 {
+		s.Params = q
+	}
+
+	return s, nil
+}
+
+// Query returns the query of the source, the getter-specific parameters
+// together with the archive, checksum, filename, archive_strip,
+// archive_member, include and exclude parameters.
+func (s *Source) Query() url.Values {
+	q := make(url.Values, len(s.Params)+7)
+	for k, v := range s.Params {
+		q[k] = append([]string(nil), v...)
+	}
+	if s.Archive != "" {
+		q.Set("archive", s.Archive)
+	}
+	if s.Checksum != "" {
+		q.Set("checksum", s.Checksum)
+	}
+	if s.Filename != "" {
+		q.Set("filename", s.Filename)
+	}
+	if s.ArchiveStrip != "" {
+		q.Set("archive_strip", s.ArchiveStrip)
+	}
+	if s.ArchiveMember != "" {
+		q.Set("archive_member", s.ArchiveMember)
+	}
+	if len(s.Include) > 0 {
+		q["include"] = append([]string(nil), s.Include...)
+	}
+	if len(s.Exclude) > 0 {
+		q["exclude"] = append([]string(nil), s.Exclude...)
+	}
+	return q
+}
+
+// String returns the source string. Parsing the result with ParseSource
+// returns an identical Source. The query is kept as it was parsed, only
+// without the parameters that were removed from the fields; once any
+// parameter is added or changed, it is encoded sorted by key.
+func (s *Source) String() string {
+	var b strings.Builder
+	if s.Getter != "" {
+		b.WriteString(s.Getter)
+		b.WriteString("::")
+	}
+	b.WriteString(s.URL)
+	if s.Subdir != "" {
+		b.WriteString("//")
+		b.WriteString(s.Subdir)
+	}
+	if q := s.encodeQuery(); q != "" {
+		b.WriteString("?")
+		b.WriteString(q)
+	}
+	if s.Fragment != "" {
+		b.WriteString("#")
+		b.WriteString(s.Fragment)
+	}
+	return b.String()
+}
+
+// encodeQuery returns the query of String.
+func (s *Source) encodeQuery() string {
+	q := s.Query()
+	parsed, err := url.ParseQuery(s.rawQuery)
+	if s.rawQuery == "" || err != nil {
+		return q.Encode()
+	}
+
+	var removed []string
+	for k, v := range parsed {
+		qv, ok := q[k]
+		switch {
+		case !ok:
+			removed = append(removed, k)
+		case !slices.Equal(v, qv):
+			return q.Encode()
+		}
+	}
+	for k := range q {
+		if _, ok := parsed[k]; !ok {
+			return q.Encode()
+		}
+	}
+	return removeQueryParams(s.rawQuery, removed...)
+}
+
+// removeQueryParams returns rawQuery without the pairs of the given keys.
+// The other pairs are kept as they are written, in their order.
+func removeQueryParams(rawQuery string, keys ...string) string {
+	if len(keys) == 0 {
+		return rawQuery
+	}
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		k, _, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(k); err == nil && slices.Contains(keys, k) {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+// takeParams removes the parameters of the given keys from the query of
+// u, with the same parsing as ParseSource, and returns u without them and
+// their values. The rest of the query is kept as it is written. Getters
+// use it to read their own parameters, such as "ref" for git.
+func takeParams(u *url.URL, keys ...string) (*url.URL, url.Values, error) {
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid query: %w", err)
+	}
+	params := make(url.Values, len(keys))
+	for _, k := range keys {
+		if v, ok := q[k]; ok {
+			params[k] = v
+		}
+	}
+
+	newU := *u
+	newU.RawQuery = removeQueryParams(u.RawQuery, keys...)
+	return &newU, params, nil
+}
+
 // SourceDirSubdir takes a source URL and returns a tuple of the URL without
 // the subdir and the subdir.
 //
 // ex:
-//   dom.com/path/?q=p               => dom.com/path/?q=p, ""
-//   proto://dom.com/path//*?q=p     => proto://dom.com/path?q=p, "*"
-//   proto://dom.com/path//path2?q=p => proto://dom.com/path?q=p, "path2"
 //
+//	dom.com/path/?q=p               => dom.com/path/?q=p, ""
+//	proto://dom.com/path//*?q=p     => proto://dom.com/path?q=p, "*"
+//	proto://dom.com/path//path2?q=p => proto://dom.com/path?q=p, "path2"
 func SourceDirSubdir(src string) (string, string) {
 
 	// URL might contains another url in query parameters
@@ -59,22 +284,90 @@ func SourceDirSubdir(src string) (string, string) {
 // download is complete) and subDir should be the set subDir. If subDir
 // is an empty string, this returns an empty string.
 //
-// The returned path is the full absolute path.
+// The returned path is the full absolute path. It is an error for subDir
+// to match multiple paths; see SubdirGlobAll.
 func SubdirGlob(dst, subDir string) (string, error) {
-	pattern := filepath.Join(dst, subDir)
-
-	matches, err := filepath.Glob(pattern)
+	matches, err := SubdirGlobAll(dst, subDir)
 	if err != nil {
 		return "", err
 	}
 
-	if len(matches) == 0 {
-		return "", fmt.Errorf("subdir %q not found", subDir)
-	}
-
 	if len(matches) > 1 {
 		return "", fmt.Errorf("subdir %q matches multiple paths", subDir)
 	}
 
 	return matches[0], nil
 }
+
+// SubdirGlobAll returns every path subDir matches in dst, sorted. Besides
+// the patterns of filepath.Match, subDir may contain brace expressions
+// like "{a,b}", which match any of their comma separated alternatives.
+// It returns an error wrapping ErrSubdirNotFound if there is no match.
+func SubdirGlobAll(dst, subDir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var matches []string
+	for _, pattern := range expandBraces(subDir) {
+		m, err := filepath.Glob(filepath.Join(dst, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range m {
+			if !seen[path] {
+				seen[path] = true
+				matches = append(matches, path)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, withSentinel(fmt.Errorf("subdir %q not found", subDir), ErrSubdirNotFound)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// expandBraces returns the patterns of pattern with its brace expressions
+// expanded, in order. Braces may be nested, and unbalanced braces are
+// kept as is.
+func expandBraces(pattern string) []string {
+	open, start, depth := -1, -1, 0
+	var alts []string
+	for i, r := range pattern {
+		switch r {
+		case '{':
+			if depth == 0 {
+				open, start = i, i
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				alts = append(alts, pattern[start+1:i])
+				start = i
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			alts = append(alts, pattern[start+1:i])
+
+			var patterns []string
+			for _, alt := range alts {
+				for _, rest := range expandBraces(alt + pattern[i+1:]) {
+					patterns = append(patterns, pattern[:open]+rest)
+				}
+			}
+			return patterns
+		}
+	}
+	return []string{pattern}
+}
+
+// hasGlob reports whether subDir is a pattern rather than a path.
+func hasGlob(subDir string) bool {
+	return strings.ContainsAny(subDir, "*?[{")
+}
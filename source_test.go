@@ -4,8 +4,10 @@
 package getter
 
 import (
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -102,3 +104,164 @@ func TestSourceSubdirGlob(t *testing.T) {
 		t.Fatalf("expected no matches, got %q", res)
 	}
 }
+
+func TestParseSource(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Source *Source
+	}{
+		{
+			"hashicorp.com",
+			&Source{URL: "hashicorp.com"},
+		},
+		{
+			"git::https://example.com/repo.git//modules/foo?ref=v1.0.0",
+			&Source{
+				Getter: "git",
+				URL:    "https://example.com/repo.git",
+				Subdir: "modules/foo",
+				Params: url.Values{"ref": {"v1.0.0"}},
+			},
+		},
+		{
+			"https://example.com/foo.zip?archive=tgz&checksum=file%3Ahttps%3A%2F%2Fexample.com%2FSUMS&filename=bar&x=1",
+			&Source{
+				URL:      "https://example.com/foo.zip",
+				Archive:  "tgz",
+				Checksum: "file:https://example.com/SUMS",
+				Filename: "bar",
+				Params:   url.Values{"x": {"1"}},
+			},
+		},
+		{
+			"gcs::https://www.googleapis.com/storage/v1/bucket/foo//*?archive=false#1234",
+			&Source{
+				Getter:   "gcs",
+				URL:      "https://www.googleapis.com/storage/v1/bucket/foo",
+				Subdir:   "*",
+				Archive:  "false",
+				Fragment: "1234",
+			},
+		},
+		{
+			"https://example.com/foo.tgz?archive_member=bin%2Ftool&archive_strip=auto",
+			&Source{URL: "https://example.com/foo.tgz", ArchiveStrip: "auto", ArchiveMember: "bin/tool"},
+		},
+		{
+			"s3::https://s3.amazonaws.com/bucket/foo?exclude=test%2F&include=%2A.tf&include=docs",
+			&Source{
+				Getter:  "s3",
+				URL:     "https://s3.amazonaws.com/bucket/foo",
+				Include: []string{"*.tf", "docs"},
+				Exclude: []string{"test/"},
+			},
+		},
+		{
+			"file:///tmp/foo#bar",
+			&Source{URL: "file:///tmp/foo", Fragment: "bar"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			s, err := ParseSource(tc.Input)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			// The raw query is checked by String below.
+			parsed := *s
+			parsed.rawQuery = ""
+			if !reflect.DeepEqual(&parsed, tc.Source) {
+				t.Fatalf("bad source\nexpected: %#v\ngot:      %#v", tc.Source, &parsed)
+			}
+
+			// The inputs are canonical so they round-trip exactly.
+			if s.String() != tc.Input {
+				t.Fatalf("bad string\nexpected: %s\ngot:      %s", tc.Input, s.String())
+			}
+		})
+	}
+}
+
+func TestParseSource_roundTrip(t *testing.T) {
+	s, err := ParseSource("git::git@github.com:hashicorp/foo.git//bar?sshkey=abc%2B%3D&ref=main&depth=1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Pin the ref, as a lockfile would.
+	s.Params.Set("ref", "0123456789abcdef")
+
+	s2, err := ParseSource(s.String())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	s.rawQuery, s2.rawQuery = "", ""
+	if !reflect.DeepEqual(s, s2) {
+		t.Fatalf("round trip mismatch\nexpected: %#v\ngot:      %#v", s, s2)
+	}
+	if v := s2.Params.Get("sshkey"); v != "abc+=" {
+		t.Fatalf("bad sshkey: %q", v)
+	}
+}
+
+func TestParseSource_invalidQuery(t *testing.T) {
+	for _, src := range []string{
+		"https://example.com/foo?sig=a;b&x=1",
+		"https://example.com/foo?a=%zz&ref=v1",
+	} {
+		if _, err := ParseSource(src); err == nil {
+			t.Fatalf("%s: expected an error", src)
+		}
+	}
+}
+
+func TestSource_StringQuery(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Edit     func(s *Source)
+		Expected string
+	}{
+		// The query is kept as written.
+		{"https://example.com/foo?z=1&k&a=%41", nil, "https://example.com/foo?z=1&k&a=%41"},
+		// Removed parameters are cut out of it.
+		{"https://example.com/foo?z=1&archive=zip&k", func(s *Source) { s.Archive = "" }, "https://example.com/foo?z=1&k"},
+		// Added or changed parameters encode it again.
+		{"https://example.com/foo?z=1&k", func(s *Source) { s.Params.Set("ref", "v1") }, "https://example.com/foo?k=&ref=v1&z=1"},
+		{"https://example.com/foo?z=1&k", func(s *Source) { s.Checksum = "md5:abc" }, "https://example.com/foo?checksum=md5%3Aabc&k=&z=1"},
+	}
+
+	for _, tc := range cases {
+		s, err := ParseSource(tc.Input)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if tc.Edit != nil {
+			tc.Edit(s)
+		}
+		if actual := s.String(); actual != tc.Expected {
+			t.Fatalf("%s: expected %s, got %s", tc.Input, tc.Expected, actual)
+		}
+	}
+}
+
+func TestTakeParams(t *testing.T) {
+	u, err := url.Parse("https://example.com/repo.git?z=1&ref=v1&depth=1&k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newU, params, err := takeParams(u, "ref", "depth", "sshkey")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if newU.String() != "https://example.com/repo.git?z=1&k" {
+		t.Fatalf("bad URL: %s", newU)
+	}
+	if u.RawQuery != "z=1&ref=v1&depth=1&k" {
+		t.Fatalf("expected the URL to be left as it was, got %s", u)
+	}
+	expected := url.Values{"ref": []string{"v1"}, "depth": []string{"1"}}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("bad params: %#v", params)
+	}
+}
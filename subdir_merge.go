@@ -0,0 +1,198 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SubdirMerge is how a subdir glob that matches multiple paths, such as
+// "//modules/*" or "//{a,b}", is copied into the destination. A match that
+// is a file rather than a directory is copied under its name.
+type SubdirMerge uint
+
+const (
+	// SubdirMergeNone fails downloads whose subdir glob matches multiple
+	// paths. It is the default.
+	SubdirMergeNone SubdirMerge = iota
+
+	// SubdirMergeKeep copies every match to its path relative to the root
+	// of the download, so "//modules/*" writes "modules/a" and
+	// "modules/b" into the destination.
+	SubdirMergeKeep
+
+	// SubdirMergeFlatten copies the content of every match into the
+	// destination itself. It is an error for two matches to contain the
+	// same file.
+	SubdirMergeFlatten
+)
+
+// subdirMerge returns the SubdirMerge of the client.
+func (c *Client) subdirMerge() SubdirMerge {
+	if c == nil {
+		return SubdirMergeNone
+	}
+	return c.SubdirMerge
+}
+
+// copySubdir copies subDir, which may be a glob, of the download at root
+// into dst on fsys, replacing what dst held. Only the files selected by
+// filter are copied; its patterns are relative to each match.
+func (c *Client) copySubdir(ctx context.Context, fsys WritableFS, dst, root, subDir string, filter *Filter) error {
+	matches, err := SubdirGlobAll(root, subDir)
+	if err != nil {
+		return err
+	}
+
+	merge := c.subdirMerge()
+	if !hasGlob(subDir) {
+		// A plain path always has its content copied.
+		merge = SubdirMergeNone
+	}
+	if merge == SubdirMergeNone && len(matches) > 1 {
+		return fmt.Errorf("subdir %q matches multiple paths", subDir)
+	}
+
+	// When merging, or when symlinks are disabled, symlinks in the
+	// download must not lead the copy out of it.
+	disableSymlinks := c != nil && c.DisableSymlinks
+	if merge != SubdirMergeNone || disableSymlinks {
+		if err := checkSubdirMatches(root, subDir, matches); err != nil {
+			return err
+		}
+	}
+	if merge == SubdirMergeFlatten {
+		if err := checkFlatten(root, matches, filter); err != nil {
+			return err
+		}
+	}
+
+	if err := fsys.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := fsys.MkdirAll(dst, c.mode(0755)); err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		rel, err := filepath.Rel(root, match)
+		if err != nil {
+			return err
+		}
+		c.observer().OnSubdirCopy(filepath.ToSlash(rel))
+
+		fi, err := os.Stat(match)
+		if err != nil {
+			return err
+		}
+
+		// Directories have their content copied into matchDst, and files
+		// are copied into it.
+		matchDst := dst
+		if merge == SubdirMergeKeep {
+			matchDst = filepath.Join(dst, rel)
+			if !fi.IsDir() {
+				matchDst = filepath.Dir(matchDst)
+			}
+		}
+		if err := fsys.MkdirAll(matchDst, c.mode(0755)); err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			if !filter.Match(fi.Name()) {
+				continue
+			}
+			_, err = copyFile(ctx, fsys, filepath.Join(matchDst, fi.Name()), match, disableSymlinks, fi.Mode(), c.umask())
+		} else {
+			err = copyDir(ctx, fsys, matchDst, match, false, disableSymlinks, c.umask(), filter)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSubdirMatches returns an ErrSymlinkCopy error if one of the matches
+// of subDir resolves outside of root through a symlink.
+func checkSubdirMatches(root, subDir string, matches []string) error {
+	resolvedRoot, err := resolveSymlinks(root)
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		resolved, err := resolveSymlinks(match)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(resolvedRoot, resolved); err != nil || containsDotDot(rel) {
+			return fmt.Errorf("subdir %q is outside of the download: %w", subDir, ErrSymlinkCopy)
+		}
+	}
+	return nil
+}
+
+// checkFlatten returns an error if two of the matches in root would write
+// the same path when flattened into one directory.
+func checkFlatten(root string, matches []string, filter *Filter) error {
+	// owners maps the paths written to the match that writes them. Only
+	// directories can be shared.
+	owners := make(map[string]string)
+	dirs := make(map[string]bool)
+	add := func(rel, match string, dir bool) error {
+		if owner, ok := owners[rel]; ok && owner != match && !(dir && dirs[rel]) {
+			ownerRel, _ := filepath.Rel(root, owner)
+			matchRel, _ := filepath.Rel(root, match)
+			return fmt.Errorf("subdirs %q and %q both contain %q",
+				filepath.ToSlash(ownerRel), filepath.ToSlash(matchRel), filepath.ToSlash(rel))
+		}
+		owners[rel] = match
+		dirs[rel] = dir
+		return nil
+	}
+
+	for _, match := range matches {
+		fi, err := os.Stat(match)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			if filter.Match(filepath.Base(match)) {
+				if err := add(filepath.Base(match), match, false); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		err = filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+			if err != nil || path == match {
+				return err
+			}
+			rel, err := filepath.Rel(match, path)
+			if err != nil {
+				return err
+			}
+			slash := filepath.ToSlash(rel)
+			if info.IsDir() {
+				if !filter.matchDir(slash) {
+					return filepath.SkipDir
+				}
+				return add(rel, match, true)
+			}
+			if !filter.Match(slash) {
+				return nil
+			}
+			return add(rel, match, false)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright IBM Corp. 2015, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package getter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testSubdirSource returns a directory with the given files, each
+// containing its path.
+func testSubdirSource(t *testing.T, files ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestWithSubdirMerge(t *testing.T) {
+	src := testSubdirSource(t, "modules/a/main.tf", "modules/b/main.tf", "modules/b/vars.tf", "README.md")
+
+	cases := []struct {
+		Subdir string
+		Merge  SubdirMerge
+		Files  []string
+		Err    string
+	}{
+		{"modules/*", SubdirMergeNone, nil, "matches multiple paths"},
+		{"modules/*", SubdirMergeKeep, []string{"modules/a/main.tf", "modules/b/main.tf", "modules/b/vars.tf"}, ""},
+		{"modules/{a,b}", SubdirMergeKeep, []string{"modules/a/main.tf", "modules/b/main.tf", "modules/b/vars.tf"}, ""},
+		{"{modules/a,README.md}", SubdirMergeKeep, []string{"README.md", "modules/a/main.tf"}, ""},
+		{"{modules/a,README.md}", SubdirMergeFlatten, []string{"README.md", "main.tf"}, ""},
+		{"modules/*", SubdirMergeFlatten, nil, `subdirs "modules/a" and "modules/b" both contain "main.tf"`},
+		{"modules/*?exclude=main.tf", SubdirMergeFlatten, []string{"vars.tf"}, ""},
+		{"modules/a", SubdirMergeKeep, []string{"main.tf"}, ""},
+		{"modules/{c,d}", SubdirMergeKeep, nil, "not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Subdir, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "target")
+			client := &Client{
+				Src:     fmtFileURL(src) + "//" + tc.Subdir,
+				Dst:     dst,
+				Mode:    ClientModeDir,
+				Options: []ClientOption{WithSubdirMerge(tc.Merge)},
+			}
+			err := client.Get()
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("expected error containing %q, got %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if files := testListFiles(t, dst); !reflect.DeepEqual(files, tc.Files) {
+				t.Fatalf("bad files: %v", files)
+			}
+		})
+	}
+}
+
+func TestWithSubdirMerge_symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not reliably available on windows")
+	}
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(td, "outside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "outside", "main.tf"), []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(td, "outside"), filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(subdir string, merge SubdirMerge, disableSymlinks bool) error {
+		client := &Client{
+			Src:             fmtFileURL(src) + "//" + subdir,
+			Dst:             filepath.Join(t.TempDir(), "target"),
+			Mode:            ClientModeDir,
+			DisableSymlinks: disableSymlinks,
+			Options:         []ClientOption{WithSubdirMerge(merge)},
+		}
+		return client.Get()
+	}
+
+	// Without merging, a subdir is followed wherever it leads, as before.
+	if err := get("link", SubdirMergeNone, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := get("lin*", SubdirMergeKeep, false); !errors.Is(err, ErrSymlinkCopy) {
+		t.Fatalf("expected a symlink error when merging, got %v", err)
+	}
+	if err := get("link", SubdirMergeNone, true); !errors.Is(err, ErrSymlinkCopy) {
+		t.Fatalf("expected a symlink error with symlinks disabled, got %v", err)
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	cases := []struct {
+		Pattern  string
+		Expanded []string
+	}{
+		{"foo", []string{"foo"}},
+		{"{a,b}", []string{"a", "b"}},
+		{"x/{a,b}/y", []string{"x/a/y", "x/b/y"}},
+		{"{a,b}{c,d}", []string{"ac", "ad", "bc", "bd"}},
+		{"{a,b{c,d}}", []string{"a", "bc", "bd"}},
+		{"{a,}", []string{"a", ""}},
+		{"{a,b", []string{"{a,b"}},
+	}
+
+	for _, tc := range cases {
+		if got := expandBraces(tc.Pattern); !reflect.DeepEqual(got, tc.Expanded) {
+			t.Fatalf("%q: expected %q, got %q", tc.Pattern, tc.Expanded, got)
+		}
+	}
+}
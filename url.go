@@ -3,7 +3,13 @@
 
 package getter
 
-import "net/url"
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	urlhelper "github.com/hashicorp/go-getter/helper/url"
+)
 
 // redactedParams is the list of URL query parameter names whose values are
 // sensitive and must be replaced with "redacted" in error messages and logs.
@@ -46,3 +52,46 @@ func RedactURL(u *url.URL) string {
 	}
 	return ru.String()
 }
+
+// redactSource is like RedactURL for a source string, which may have a
+// forced getter. Sources that are not valid URLs, such as scp-like git
+// addresses, only have their sensitive query parameters redacted.
+func redactSource(src string) string {
+	force, raw := getForcedGetter(src)
+	u, err := urlhelper.Parse(raw)
+	if err != nil {
+		return redactQuery(src)
+	}
+	if force != "" {
+		return force + "::" + RedactURL(u)
+	}
+	return RedactURL(u)
+}
+
+// redactQuery replaces the values of the sensitive query parameters of src
+// with "redacted", leaving the rest of src as is.
+func redactQuery(src string) string {
+	rest, query, ok := strings.Cut(src, "?")
+	if !ok {
+		return src
+	}
+	query, fragment, hasFragment := strings.Cut(query, "#")
+
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if slices.Contains(redactedParams, name) {
+			pairs[i] = key + "=redacted"
+		}
+	}
+
+	src = rest + "?" + strings.Join(pairs, "&")
+	if hasFragment {
+		src += "#" + fragment
+	}
+	return src
+}